@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/lwlcom/cisco_exporter/connector"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// backgroundScraper scrapes every device in its own interval and keeps the result of the last scrape
+type backgroundScraper struct {
+	collector *ciscoCollector
+	snapshots map[string]*snapshot
+	runs      map[string]*scrapeRun
+	mu        sync.RWMutex
+	ctx       context.Context
+	cancel    context.CancelFunc
+
+	lastScrapeDesc *prometheus.Desc
+	staleDesc      *prometheus.Desc
+}
+
+// scrapeRun scrapes a device in its interval until it is canceled
+type scrapeRun struct {
+	device *connector.Device
+	cancel context.CancelFunc
+}
+
+type snapshot struct {
+	metrics     []prometheus.Metric
+	labelValues []string
+	timestamp   time.Time
+	interval    time.Duration
+}
+
+func newBackgroundScraper(devices []*connector.Device) *backgroundScraper {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s := &backgroundScraper{
+		collector: newCiscoCollector(ctx, devices),
+		snapshots: make(map[string]*snapshot),
+		runs:      make(map[string]*scrapeRun),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+
+	l := s.collector.collectors.labelNames
+	s.lastScrapeDesc = prometheus.NewDesc(prefix+"last_scrape_timestamp_seconds", "Time of the last background scrape of target", l, nil)
+	s.staleDesc = prometheus.NewDesc(prefix+"scrape_stale", "Last background scrape of target is older than twice the scrape interval", l, nil)
+
+	return s
+}
+
+// inheritSnapshots takes over the snapshots of devices still scraped with the same labels from a previous scraper,
+// so they are not missing until the first scrape after a config reload
+func (s *backgroundScraper) inheritSnapshots(previous *backgroundScraper) {
+	if previous == nil {
+		return
+	}
+
+	previous.mu.RLock()
+	defer previous.mu.RUnlock()
+
+	if !reflect.DeepEqual(previous.collector.collectors.labelNames, s.collector.collectors.labelNames) {
+		return
+	}
+
+	for _, d := range s.collector.devices {
+		snap, found := previous.snapshots[d.Host]
+		if found && reflect.DeepEqual(snap.labelValues, s.collector.collectors.labelValues(d)) {
+			s.snapshots[d.Host] = snap
+		}
+	}
+}
+
+func (s *backgroundScraper) start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, d := range s.collector.devices {
+		s.startRun(d)
+	}
+}
+
+// updateDevices changes the devices to scrape. Devices whose config did not change keep being scraped in their interval,
+// devices removed are not scraped anymore and their snapshots are dropped. Returns false if the label names changed,
+// the scraper has to be replaced then
+func (s *backgroundScraper) updateDevices(devices []*connector.Device) bool {
+	c := newCiscoCollector(s.ctx, devices)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !reflect.DeepEqual(c.collectors.labelNames, s.collector.collectors.labelNames) {
+		return false
+	}
+	s.collector = c
+
+	hosts := make(map[string]bool)
+	for _, d := range devices {
+		hosts[d.Host] = true
+
+		r, found := s.runs[d.Host]
+		if found && reflect.DeepEqual(r.device.DeviceConfig, d.DeviceConfig) {
+			continue
+		}
+		if found {
+			r.cancel()
+			delete(s.snapshots, d.Host)
+		}
+		s.startRun(d)
+	}
+
+	for host, r := range s.runs {
+		if !hosts[host] {
+			r.cancel()
+			delete(s.runs, host)
+			delete(s.snapshots, host)
+		}
+	}
+
+	return true
+}
+
+// startRun starts scraping a device. Has to be called while holding mu
+func (s *backgroundScraper) startRun(device *connector.Device) {
+	interval := s.collector.cfg.ScrapeInterval
+	if device.DeviceConfig.ScrapeInterval != nil && *device.DeviceConfig.ScrapeInterval > 0 {
+		interval = *device.DeviceConfig.ScrapeInterval
+	}
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	s.runs[device.Host] = &scrapeRun{
+		device: device,
+		cancel: cancel,
+	}
+
+	go s.run(ctx, device, interval)
+}
+
+// shutdown stops scraping and aborts running scrapes
+func (s *backgroundScraper) shutdown() {
+	s.cancel()
+}
+
+func (s *backgroundScraper) run(ctx context.Context, device *connector.Device, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.scrape(ctx, device, interval)
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *backgroundScraper) scrape(runCtx context.Context, device *connector.Device, interval time.Duration) {
+	ctx, cancel := context.WithTimeout(runCtx, interval)
+	defer cancel()
+
+	s.mu.RLock()
+	collector := s.collector
+	s.mu.RUnlock()
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		collector.collectForHost(ctx, device, ch)
+		close(ch)
+	}()
+
+	metrics := make([]prometheus.Metric, 0)
+	for m := range ch {
+		metrics = append(metrics, m)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// the device was removed or its config changed while scraping
+	if runCtx.Err() != nil {
+		return
+	}
+
+	s.snapshots[device.Host] = &snapshot{
+		metrics:     metrics,
+		labelValues: collector.collectors.labelValues(device),
+		timestamp:   time.Now(),
+		interval:    interval,
+	}
+}
+
+// Describe implements prometheus.Collector interface
+func (s *backgroundScraper) Describe(ch chan<- *prometheus.Desc) {
+	ch <- s.lastScrapeDesc
+	ch <- s.staleDesc
+
+	s.mu.RLock()
+	collector := s.collector
+	s.mu.RUnlock()
+
+	collector.Describe(ch)
+}
+
+// Collect implements prometheus.Collector interface
+func (s *backgroundScraper) Collect(ch chan<- prometheus.Metric) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, snap := range s.snapshots {
+		for _, m := range snap.metrics {
+			ch <- m
+		}
+
+		stale := 0
+		if time.Since(snap.timestamp) > 2*snap.interval {
+			stale = 1
+		}
+
+		ch <- prometheus.MustNewConstMetric(s.lastScrapeDesc, prometheus.GaugeValue, float64(snap.timestamp.Unix()), snap.labelValues...)
+		ch <- prometheus.MustNewConstMetric(s.staleDesc, prometheus.GaugeValue, float64(stale), snap.labelValues...)
+	}
+}
@@ -1,6 +1,8 @@
 package bgp
 
 import (
+	"context"
+	"errors"
 	"log"
 
 	"github.com/lwlcom/cisco_exporter/rpc"
@@ -11,27 +13,24 @@ import (
 
 const prefix string = "cisco_bgp_session_"
 
-var (
+type bgpCollector struct {
 	upDesc               *prometheus.Desc
 	receivedPrefixesDesc *prometheus.Desc
 	inputMessagesDesc    *prometheus.Desc
 	outputMessagesDesc   *prometheus.Desc
-)
-
-func init() {
-	l := []string{"target", "asn", "ip"}
-	upDesc = prometheus.NewDesc(prefix+"up", "Session is up (1 = Established)", l, nil)
-	receivedPrefixesDesc = prometheus.NewDesc(prefix+"prefixes_received_count", "Number of received prefixes", l, nil)
-	inputMessagesDesc = prometheus.NewDesc(prefix+"messages_input_count", "Number of received messages", l, nil)
-	outputMessagesDesc = prometheus.NewDesc(prefix+"messages_output_count", "Number of transmitted messages", l, nil)
 }
 
-type bgpCollector struct {
-}
+// NewCollector creates a new collector. labels are the names of the labels identifying the target
+func NewCollector(labels []string) collector.RPCCollector {
+	c := &bgpCollector{}
+
+	l := append(labels, "asn", "ip")
+	c.upDesc = prometheus.NewDesc(prefix+"up", "Session is up (1 = Established)", l, nil)
+	c.receivedPrefixesDesc = prometheus.NewDesc(prefix+"prefixes_received_count", "Number of received prefixes", l, nil)
+	c.inputMessagesDesc = prometheus.NewDesc(prefix+"messages_input_count", "Number of received messages", l, nil)
+	c.outputMessagesDesc = prometheus.NewDesc(prefix+"messages_output_count", "Number of transmitted messages", l, nil)
 
-// NewCollector creates a new collector
-func NewCollector() collector.RPCCollector {
-	return &bgpCollector{}
+	return c
 }
 
 // Name returns the name of the collector
@@ -40,25 +39,38 @@ func (*bgpCollector) Name() string {
 }
 
 // Describe describes the metrics
-func (*bgpCollector) Describe(ch chan<- *prometheus.Desc) {
-	ch <- upDesc
-	ch <- receivedPrefixesDesc
-	ch <- inputMessagesDesc
-	ch <- outputMessagesDesc
+func (c *bgpCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.upDesc
+	ch <- c.receivedPrefixesDesc
+	ch <- c.inputMessagesDesc
+	ch <- c.outputMessagesDesc
+}
+
+// SupportsOper checks if the sessions can be collected from the YANG model
+func (c *bgpCollector) SupportsOper(client *rpc.Client) bool {
+	return client.HasModule(operModule)
 }
 
 // Collect collects metrics from Cisco
-func (c *bgpCollector) Collect(client *rpc.Client, ch chan<- prometheus.Metric, labelValues []string) error {
-	out, err := client.RunCommand("show bgp all summary")
-	if err != nil {
-		return err
+func (c *bgpCollector) Collect(ctx context.Context, client *rpc.Client, ch chan<- prometheus.Metric, labelValues []string) error {
+	var items []BgpSession
+	var err error
+	switch {
+	case client.HasModule(operModule):
+		items, err = c.getOper(ctx, client)
+	case client.OSType == rpc.NXOS:
+		items, err = c.getJSON(ctx, client)
+		if errors.Is(err, rpc.ErrNoJSON) {
+			if client.Debug {
+				log.Printf("Parse bgp sessions JSON for %s: %s\n", labelValues[0], err.Error())
+			}
+			items, err = c.get(ctx, client, labelValues)
+		}
+	default:
+		items, err = c.get(ctx, client, labelValues)
 	}
-	items, err := c.Parse(client.OSType, out)
 	if err != nil {
-		if client.Debug {
-			log.Printf("Parse bgp sessions for %s: %s\n", labelValues[0], err.Error())
-		}
-		return nil
+		return err
 	}
 
 	for _, item := range items {
@@ -69,11 +81,32 @@ func (c *bgpCollector) Collect(client *rpc.Client, ch chan<- prometheus.Metric,
 			up = 1
 		}
 
-		ch <- prometheus.MustNewConstMetric(upDesc, prometheus.GaugeValue, float64(up), l...)
-		ch <- prometheus.MustNewConstMetric(receivedPrefixesDesc, prometheus.GaugeValue, float64(item.ReceivedPrefixes), l...)
-		ch <- prometheus.MustNewConstMetric(inputMessagesDesc, prometheus.GaugeValue, float64(item.InputMessages), l...)
-		ch <- prometheus.MustNewConstMetric(outputMessagesDesc, prometheus.GaugeValue, float64(item.OutputMessages), l...)
+		ch <- prometheus.MustNewConstMetric(c.upDesc, prometheus.GaugeValue, float64(up), l...)
+		ch <- prometheus.MustNewConstMetric(c.receivedPrefixesDesc, prometheus.GaugeValue, float64(item.ReceivedPrefixes), l...)
+		ch <- prometheus.MustNewConstMetric(c.inputMessagesDesc, prometheus.GaugeValue, float64(item.InputMessages), l...)
+		ch <- prometheus.MustNewConstMetric(c.outputMessagesDesc, prometheus.GaugeValue, float64(item.OutputMessages), l...)
 	}
 
 	return nil
 }
+
+// get gets the sessions from the CLI
+func (c *bgpCollector) get(ctx context.Context, client *rpc.Client, labelValues []string) ([]BgpSession, error) {
+	cmd := "show bgp all summary"
+	if client.OSType == rpc.IOSXR {
+		cmd = "show bgp all all summary"
+	}
+	out, err := client.RunCommand(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
+	items, err := c.Parse(client.OSType, out)
+	if err != nil {
+		if client.Debug {
+			log.Printf("Parse bgp sessions for %s: %s\n", labelValues[0], err.Error())
+		}
+		return nil, nil
+	}
+
+	return items, nil
+}
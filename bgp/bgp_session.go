@@ -8,3 +8,41 @@ type BgpSession struct {
 	InputMessages    float64
 	OutputMessages   float64
 }
+
+// neighborSessions collects the sessions by neighbor IP, as the metrics have no label for the VRF.
+// Entries for further address families of a session only add their received prefixes, sessions to the same
+// neighbor IP in other VRFs add their prefixes and messages and are only up if all of them are
+type neighborSessions struct {
+	items    []BgpSession
+	sessions map[string]int
+	vrfs     map[string]bool
+}
+
+func newNeighborSessions() *neighborSessions {
+	return &neighborSessions{
+		items:    []BgpSession{},
+		sessions: make(map[string]int),
+		vrfs:     make(map[string]bool),
+	}
+}
+
+func (n *neighborSessions) add(vrf string, s BgpSession) {
+	i, found := n.sessions[s.IP]
+	if !found {
+		n.sessions[s.IP] = len(n.items)
+		n.vrfs[vrf+"/"+s.IP] = true
+		n.items = append(n.items, s)
+		return
+	}
+
+	item := &n.items[i]
+	item.ReceivedPrefixes += s.ReceivedPrefixes
+	if n.vrfs[vrf+"/"+s.IP] {
+		return
+	}
+
+	n.vrfs[vrf+"/"+s.IP] = true
+	item.Up = item.Up && s.Up
+	item.InputMessages += s.InputMessages
+	item.OutputMessages += s.OutputMessages
+}
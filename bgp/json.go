@@ -0,0 +1,66 @@
+package bgp
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/lwlcom/cisco_exporter/rpc"
+)
+
+type bgpJSON struct {
+	Vrfs struct {
+		Rows []struct {
+			Name string `json:"vrf-name-out"`
+			Afs  struct {
+				Rows []struct {
+					Safs struct {
+						Rows []struct {
+							Neighbors struct {
+								Rows []neighborJSON `json:"ROW_neighbor"`
+							} `json:"TABLE_neighbor"`
+						} `json:"ROW_saf"`
+					} `json:"TABLE_saf"`
+				} `json:"ROW_af"`
+			} `json:"TABLE_af"`
+		} `json:"ROW_vrf"`
+	} `json:"TABLE_vrf"`
+}
+
+type neighborJSON struct {
+	NeighborID     string      `json:"neighborid"`
+	AS             json.Number `json:"neighboras"`
+	State          string      `json:"state"`
+	MsgRecvd       rpc.Number  `json:"msgrecvd"`
+	MsgSent        rpc.Number  `json:"msgsent"`
+	PrefixReceived rpc.Number  `json:"prefixreceived"`
+}
+
+// getJSON gets the sessions from the JSON output of show bgp all summary (NX-OS).
+// Sessions with multiple address families or in multiple VRFs are merged into one session per neighbor
+func (c *bgpCollector) getJSON(ctx context.Context, client *rpc.Client) ([]BgpSession, error) {
+	var data bgpJSON
+	err := client.RunCommandJSON(ctx, "show bgp all summary", &data)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := newNeighborSessions()
+	for _, vrf := range data.Vrfs.Rows {
+		for _, af := range vrf.Afs.Rows {
+			for _, saf := range af.Safs.Rows {
+				for _, n := range saf.Neighbors.Rows {
+					sessions.add(vrf.Name, BgpSession{
+						IP:               n.NeighborID,
+						Asn:              n.AS.String(),
+						Up:               n.State == "Established",
+						ReceivedPrefixes: float64(n.PrefixReceived),
+						InputMessages:    float64(n.MsgRecvd),
+						OutputMessages:   float64(n.MsgSent),
+					})
+				}
+			}
+		}
+	}
+
+	return sessions.items, nil
+}
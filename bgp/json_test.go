@@ -0,0 +1,90 @@
+package bgp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lwlcom/cisco_exporter/connector"
+	"github.com/lwlcom/cisco_exporter/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// fakeTransport answers commands with fixed outputs
+type fakeTransport struct {
+	connector.Transport
+	outputs map[string]string
+}
+
+func (t *fakeTransport) Host() string { return "fake" }
+
+func (t *fakeTransport) RunCommand(ctx context.Context, cmd string) (string, error) {
+	return t.outputs[cmd], nil
+}
+
+// summaryVrfsJSON has a neighbor with two address families in the default VRF and the same neighbor IP in VRF customer
+const summaryVrfsJSON = `{
+  "TABLE_vrf": {"ROW_vrf": [
+    {"vrf-name-out": "default", "TABLE_af": {"ROW_af": [
+      {"TABLE_saf": {"ROW_saf": [{"TABLE_neighbor": {"ROW_neighbor": [
+        {"neighborid": "192.0.2.1", "neighboras": "65001", "state": "Established", "msgrecvd": "1200", "msgsent": "1100", "prefixreceived": "10"},
+        {"neighborid": "192.0.2.2", "neighboras": "65002", "state": "Idle", "msgrecvd": "300", "msgsent": "310", "prefixreceived": "0"}
+      ]}}]}},
+      {"TABLE_saf": {"ROW_saf": [{"TABLE_neighbor": {"ROW_neighbor": [
+        {"neighborid": "192.0.2.1", "neighboras": "65001", "state": "Established", "msgrecvd": "1200", "msgsent": "1100", "prefixreceived": "5"}
+      ]}}]}}
+    ]}},
+    {"vrf-name-out": "customer", "TABLE_af": {"ROW_af": [
+      {"TABLE_saf": {"ROW_saf": [{"TABLE_neighbor": {"ROW_neighbor": [
+        {"neighborid": "192.0.2.1", "neighboras": "65001", "state": "Established", "msgrecvd": "100", "msgsent": "90", "prefixreceived": "2"}
+      ]}}]}}
+    ]}}
+  ]}
+}`
+
+// scrape runs a collector against a client when registered
+type scrape struct {
+	collector *bgpCollector
+	client    *rpc.Client
+}
+
+func (s *scrape) Describe(ch chan<- *prometheus.Desc) {
+	s.collector.Describe(ch)
+}
+
+func (s *scrape) Collect(ch chan<- prometheus.Metric) {
+	s.collector.Collect(context.Background(), s.client, ch, []string{"nx"})
+}
+
+func TestGetJSONMergesVrfs(t *testing.T) {
+	client := rpc.NewClient(&fakeTransport{outputs: map[string]string{"show bgp all summary | json": summaryVrfsJSON}}, false)
+	client.OSType = rpc.NXOS
+	client.NoJSON = make(map[string]bool)
+	c := NewCollector([]string{"target"}).(*bgpCollector)
+
+	items, err := c.getJSON(context.Background(), client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items[0].IP != "192.0.2.1" || items[1].IP != "192.0.2.2" {
+		t.Fatalf("unexpected sessions %+v", items)
+	}
+	if s := items[0]; !s.Up || s.ReceivedPrefixes != 17 || s.InputMessages != 1300 || s.OutputMessages != 1190 {
+		t.Fatalf("unexpected merged session %+v", s)
+	}
+
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(&scrape{collector: c, client: client})
+	if _, err = reg.Gather(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNeighborSessionsDownInOneVrf(t *testing.T) {
+	sessions := newNeighborSessions()
+	sessions.add("default", BgpSession{IP: "192.0.2.1", Up: true, ReceivedPrefixes: 10})
+	sessions.add("customer", BgpSession{IP: "192.0.2.1", Up: false})
+
+	if len(sessions.items) != 1 || sessions.items[0].Up {
+		t.Fatalf("unexpected sessions %+v", sessions.items)
+	}
+}
@@ -0,0 +1,76 @@
+package bgp
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/lwlcom/cisco_exporter/rpc"
+)
+
+const operModule = "Cisco-IOS-XE-bgp-oper"
+
+const operFilter = `<bgp-state-data xmlns="http://cisco.com/ns/yang/Cisco-IOS-XE-bgp-oper"><neighbors/></bgp-state-data>`
+
+const operPath = "Cisco-IOS-XE-bgp-oper:bgp-state-data/neighbors"
+
+type bgpOper struct {
+	Neighbors struct {
+		Neighbor []neighborOper `xml:"neighbor" json:"neighbor"`
+	} `xml:"bgp-state-data>neighbors" json:"Cisco-IOS-XE-bgp-oper:neighbors"`
+}
+
+type neighborOper struct {
+	VrfName        string           `xml:"vrf-name" json:"vrf-name"`
+	NeighborID     string           `xml:"neighbor-id" json:"neighbor-id"`
+	AS             rpc.Number       `xml:"as" json:"as"`
+	SessionState   string           `xml:"session-state" json:"session-state"`
+	Counters       neighborCounters `xml:"bgp-neighbor-counters" json:"bgp-neighbor-counters"`
+	PrefixActivity neighborPrefixes `xml:"prefix-activity" json:"prefix-activity"`
+}
+
+type neighborCounters struct {
+	Sent     messageCounters `xml:"sent" json:"sent"`
+	Received messageCounters `xml:"received" json:"received"`
+}
+
+type messageCounters struct {
+	Opens          rpc.Number `xml:"opens" json:"opens"`
+	Updates        rpc.Number `xml:"updates" json:"updates"`
+	Notifications  rpc.Number `xml:"notifications" json:"notifications"`
+	Keepalives     rpc.Number `xml:"keepalives" json:"keepalives"`
+	RouteRefreshes rpc.Number `xml:"route-refreshes" json:"route-refreshes"`
+}
+
+func (m messageCounters) total() float64 {
+	return float64(m.Opens + m.Updates + m.Notifications + m.Keepalives + m.RouteRefreshes)
+}
+
+type neighborPrefixes struct {
+	Received struct {
+		CurrentPrefixes rpc.Number `xml:"current-prefixes" json:"current-prefixes"`
+	} `xml:"received" json:"received"`
+}
+
+// getOper gets the sessions from the Cisco-IOS-XE-bgp-oper model. The model has an entry for every address family
+// of a neighbor in a VRF, these are merged into one session per neighbor like in the CLI output
+func (c *bgpCollector) getOper(ctx context.Context, client *rpc.Client) ([]BgpSession, error) {
+	var data bgpOper
+	err := client.GetOper(ctx, operFilter, operPath, &data)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := newNeighborSessions()
+	for _, n := range data.Neighbors.Neighbor {
+		sessions.add(n.VrfName, BgpSession{
+			IP:               n.NeighborID,
+			Asn:              strconv.FormatUint(uint64(n.AS), 10),
+			Up:               n.SessionState == "fsm-established",
+			ReceivedPrefixes: float64(n.PrefixActivity.Received.CurrentPrefixes),
+			InputMessages:    n.Counters.Received.total(),
+			OutputMessages:   n.Counters.Sent.total(),
+		})
+	}
+
+	return sessions.items, nil
+}
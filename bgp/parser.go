@@ -10,10 +10,11 @@ import (
 
 // Parse parses cli output and tries to find bgp sessions with related data
 func (c *bgpCollector) Parse(ostype string, output string) ([]BgpSession, error) {
-	if ostype != rpc.IOSXE && ostype != rpc.NXOS {
+	if ostype != rpc.IOSXE && ostype != rpc.NXOS && ostype != rpc.IOSXR {
 		return nil, errors.New("'show bgp all summary' is not implemented for " + ostype)
 	}
 	items := []BgpSession{}
+	sessions := make(map[string]int)
 	neighborRegexp, _ := regexp.Compile(`(\S+)\s+\d\s+(\d+)\s+(\d+)\s+(\d+)\s+\d+\s+\d+\s+\d+\s+\S+\s+(\S+)\s*`)
 
 	matches := neighborRegexp.FindAllStringSubmatch(output, -1)
@@ -25,6 +26,15 @@ func (c *bgpCollector) Parse(ostype string, output string) ([]BgpSession, error)
 			up = false
 		}
 
+		// IOS-XR lists neighbors once for every address family
+		if ostype == rpc.IOSXR {
+			if i, found := sessions[match[1]]; found {
+				items[i].ReceivedPrefixes += pref
+				continue
+			}
+			sessions[match[1]] = len(items)
+		}
+
 		item := BgpSession{
 			IP:               match[1],
 			Asn:              match[2],
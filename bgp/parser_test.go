@@ -0,0 +1,41 @@
+package bgp
+
+import (
+	"testing"
+
+	"github.com/lwlcom/cisco_exporter/rpc"
+)
+
+const summaryTwoFamilies = `
+Neighbor        V           AS MsgRcvd MsgSent   TblVer  InQ OutQ Up/Down  State/PfxRcd
+192.0.2.1       4        65001    1200    1100       42    0    0 1w2d           10
+192.0.2.2       4        65002     300     310       42    0    0 00:10:00 Idle
+192.0.2.1       4        65001    1200    1100       42    0    0 1w2d            5
+`
+
+func TestParseMergesAddressFamiliesOnIOSXR(t *testing.T) {
+	c := &bgpCollector{}
+
+	items, err := c.Parse(rpc.IOSXR, summaryTwoFamilies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items[0].IP != "192.0.2.1" || items[0].ReceivedPrefixes != 15 {
+		t.Fatalf("unexpected sessions %+v", items)
+	}
+	if items[1].Up {
+		t.Fatal("idle session reported as up")
+	}
+}
+
+func TestParseKeepsSessionsOnIOSXE(t *testing.T) {
+	c := &bgpCollector{}
+
+	items, err := c.Parse(rpc.IOSXE, summaryTwoFamilies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 3 || items[0].ReceivedPrefixes != 10 || items[2].ReceivedPrefixes != 5 {
+		t.Fatalf("unexpected sessions %+v", items)
+	}
+}
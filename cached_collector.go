@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lwlcom/cisco_exporter/collector"
+	"github.com/lwlcom/cisco_exporter/connector"
+	"github.com/lwlcom/cisco_exporter/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// collectorCache holds the metrics of the last run of collectors having a minimum interval
+type collectorCache struct {
+	entries map[string]*cacheEntry
+	mu      sync.Mutex
+}
+
+type cacheEntry struct {
+	target    string
+	metrics   []prometheus.Metric
+	timestamp time.Time
+	maxAge    time.Duration
+}
+
+func newCollectorCache() *collectorCache {
+	return &collectorCache{
+		entries: make(map[string]*cacheEntry),
+	}
+}
+
+func (c *collectorCache) get(key string, maxAge time.Duration) ([]prometheus.Metric, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, found := c.entries[key]
+	if !found || time.Since(e.timestamp) >= maxAge {
+		return nil, false
+	}
+
+	return e.metrics, true
+}
+
+// set stores the metrics of a collector run. Expired entries, e.g. of targets only probed once, are removed
+func (c *collectorCache) set(key, target string, metrics []prometheus.Metric, maxAge time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for k, e := range c.entries {
+		if time.Since(e.timestamp) >= e.maxAge {
+			delete(c.entries, k)
+		}
+	}
+
+	c.entries[key] = &cacheEntry{
+		target:    target,
+		metrics:   metrics,
+		timestamp: time.Now(),
+		maxAge:    maxAge,
+	}
+}
+
+// retain removes the entries of all targets not in devices
+func (c *collectorCache) retain(devices []*connector.Device) {
+	targets := make(map[string]bool)
+	for _, d := range devices {
+		targets[d.Host] = true
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for k, e := range c.entries {
+		if !targets[e.target] {
+			delete(c.entries, k)
+		}
+	}
+}
+
+// cachedCollector runs the wrapped collector at most once per interval and serves the cached metrics in between
+type cachedCollector struct {
+	collector.RPCCollector
+	interval time.Duration
+	cache    *collectorCache
+}
+
+func newCachedCollector(col collector.RPCCollector, interval time.Duration, cache *collectorCache) collector.RPCCollector {
+	return &cachedCollector{
+		RPCCollector: col,
+		interval:     interval,
+		cache:        cache,
+	}
+}
+
+// SupportsOper checks if the wrapped collector can get its data from YANG models
+func (c *cachedCollector) SupportsOper(client *rpc.Client) bool {
+	return collector.SupportsOper(c.RPCCollector, client)
+}
+
+// Collect collects metrics from Cisco or the cache
+func (c *cachedCollector) Collect(ctx context.Context, client *rpc.Client, ch chan<- prometheus.Metric, labelValues []string) error {
+	key := c.Name() + "/" + strings.Join(labelValues, "/")
+
+	if metrics, found := c.cache.get(key, c.interval); found {
+		for _, m := range metrics {
+			ch <- m
+		}
+		return nil
+	}
+
+	metrics := make([]prometheus.Metric, 0)
+	metricsChan := make(chan prometheus.Metric)
+	done := make(chan struct{})
+	go func() {
+		for m := range metricsChan {
+			metrics = append(metrics, m)
+			ch <- m
+		}
+		close(done)
+	}()
+
+	err := c.RPCCollector.Collect(ctx, client, metricsChan, labelValues)
+	close(metricsChan)
+	<-done
+
+	if err == nil {
+		c.cache.set(key, labelValues[0], metrics, c.interval)
+	}
+
+	return err
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lwlcom/cisco_exporter/connector"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestCollectorCacheRetain(t *testing.T) {
+	c := newCollectorCache()
+	c.set("Facts/router1", "router1", []prometheus.Metric{}, time.Hour)
+	c.set("Facts/router2", "router2", []prometheus.Metric{}, time.Hour)
+
+	c.retain([]*connector.Device{{Host: "router1"}})
+
+	if _, found := c.get("Facts/router1", time.Hour); !found {
+		t.Fatal("entry of configured device was removed")
+	}
+	if _, found := c.entries["Facts/router2"]; found {
+		t.Fatal("entry of removed device was not removed")
+	}
+}
+
+func TestCollectorCacheRemovesExpired(t *testing.T) {
+	c := newCollectorCache()
+	c.set("Facts/probe", "probe", []prometheus.Metric{}, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	c.set("Facts/router1", "router1", []prometheus.Metric{}, time.Hour)
+
+	if _, found := c.entries["Facts/probe"]; found {
+		t.Fatal("expired entry was not removed")
+	}
+	if len(c.entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(c.entries))
+	}
+}
@@ -1,10 +1,14 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"time"
 
 	"sync"
 
+	"github.com/lwlcom/cisco_exporter/collector"
+	"github.com/lwlcom/cisco_exporter/config"
 	"github.com/lwlcom/cisco_exporter/connector"
 	"github.com/lwlcom/cisco_exporter/rpc"
 	"github.com/prometheus/client_golang/prometheus"
@@ -13,35 +17,62 @@ import (
 
 const prefix = "cisco_"
 
-var (
-	scrapeCollectorDurationDesc *prometheus.Desc
-	scrapeDurationDesc          *prometheus.Desc
-	upDesc                      *prometheus.Desc
-)
-
-func init() {
-	upDesc = prometheus.NewDesc(prefix+"up", "Scrape of target was successful", []string{"target"}, nil)
-	scrapeDurationDesc = prometheus.NewDesc(prefix+"collector_duration_seconds", "Duration of a collector scrape for one target", []string{"target"}, nil)
-	scrapeCollectorDurationDesc = prometheus.NewDesc(prefix+"collect_duration_seconds", "Duration of a scrape by collector and target", []string{"target", "collector"}, nil)
-}
-
 type ciscoCollector struct {
+	ctx        context.Context
+	cfg        *config.Config
+	limiter    *scrapeLimiter
 	devices    []*connector.Device
 	collectors *collectors
+
+	hostKeyErrorDesc            *prometheus.Desc
+	queueWaitDesc               *prometheus.Desc
+	scrapeCollectorDurationDesc *prometheus.Desc
+	scrapeDurationDesc          *prometheus.Desc
+	upDesc                      *prometheus.Desc
 }
 
-func newCiscoCollector(devices []*connector.Device) *ciscoCollector {
-	return &ciscoCollector{
+func newCiscoCollector(ctx context.Context, devices []*connector.Device) *ciscoCollector {
+	c := &ciscoCollector{
+		ctx:        ctx,
+		cfg:        cfg,
+		limiter:    limiter,
 		devices:    devices,
 		collectors: collectorsForDevices(devices, cfg),
 	}
+	c.initDescs()
+
+	return c
+}
+
+func newProbeCollector(ctx context.Context, device *connector.Device, features *config.FeatureConfig) *ciscoCollector {
+	c := &ciscoCollector{
+		ctx:        ctx,
+		cfg:        cfg,
+		limiter:    limiter,
+		devices:    []*connector.Device{device},
+		collectors: collectorsForProbe(device, features, cfg),
+	}
+	c.initDescs()
+
+	return c
+}
+
+func (c *ciscoCollector) initDescs() {
+	l := c.collectors.labelNames
+	c.upDesc = prometheus.NewDesc(prefix+"up", "Scrape of target was successful", l, nil)
+	c.scrapeDurationDesc = prometheus.NewDesc(prefix+"collector_duration_seconds", "Duration of a collector scrape for one target", l, nil)
+	c.scrapeCollectorDurationDesc = prometheus.NewDesc(prefix+"collect_duration_seconds", "Duration of a scrape by collector and target", append(l, "collector"), nil)
+	c.queueWaitDesc = prometheus.NewDesc(prefix+"scrape_queue_wait_seconds", "Time target waited for a free scrape slot", l, nil)
+	c.hostKeyErrorDesc = prometheus.NewDesc(prefix+"host_key_error", "Host key of target could not be verified (1 = verification failed)", l, nil)
 }
 
 // Describe implements prometheus.Collector interface
 func (c *ciscoCollector) Describe(ch chan<- *prometheus.Desc) {
-	ch <- upDesc
-	ch <- scrapeDurationDesc
-	ch <- scrapeCollectorDurationDesc
+	ch <- c.upDesc
+	ch <- c.hostKeyErrorDesc
+	ch <- c.scrapeDurationDesc
+	ch <- c.scrapeCollectorDurationDesc
+	ch <- c.queueWaitDesc
 
 	for _, col := range c.collectors.allEnabledCollectors() {
 		col.Describe(ch)
@@ -54,47 +85,122 @@ func (c *ciscoCollector) Collect(ch chan<- prometheus.Metric) {
 
 	wg.Add(len(c.devices))
 	for _, d := range c.devices {
-		go c.collectForHost(d, ch, wg)
+		go func(d *connector.Device) {
+			defer wg.Done()
+			c.collectForHost(c.ctx, d, ch)
+		}(d)
 	}
 
 	wg.Wait()
 }
 
-func (c *ciscoCollector) collectForHost(device *connector.Device, ch chan<- prometheus.Metric, wg *sync.WaitGroup) {
-	defer wg.Done()
+func (c *ciscoCollector) collectForHost(ctx context.Context, device *connector.Device, ch chan<- prometheus.Metric) {
+	l := c.collectors.labelValues(device)
 
-	l := []string{device.Host}
+	wait, err := c.limiter.acquire(ctx)
+	ch <- prometheus.MustNewConstMetric(c.queueWaitDesc, prometheus.GaugeValue, wait.Seconds(), l...)
+	if err != nil {
+		log.Errorln(device.Host + ": " + err.Error())
+		ch <- prometheus.MustNewConstMetric(c.upDesc, prometheus.GaugeValue, 0, l...)
+		return
+	}
+	defer c.limiter.release()
 
 	t := time.Now()
 	defer func() {
-		ch <- prometheus.MustNewConstMetric(scrapeDurationDesc, prometheus.GaugeValue, time.Since(t).Seconds(), l...)
+		ch <- prometheus.MustNewConstMetric(c.scrapeDurationDesc, prometheus.GaugeValue, time.Since(t).Seconds(), l...)
 	}()
 
-	conn, err := connector.NewSSSHConnection(device, cfg)
+	conn, err := connManager.Acquire(ctx, device, c.cfg)
 	if err != nil {
 		log.Errorln(err)
-		ch <- prometheus.MustNewConstMetric(upDesc, prometheus.GaugeValue, 0, l...)
+		ch <- prometheus.MustNewConstMetric(c.upDesc, prometheus.GaugeValue, 0, l...)
+
+		var hostKeyErr *connector.HostKeyError
+		if errors.As(err, &hostKeyErr) {
+			ch <- prometheus.MustNewConstMetric(c.hostKeyErrorDesc, prometheus.GaugeValue, 1, l...)
+		}
 		return
 	}
-	defer conn.Close()
-
-	ch <- prometheus.MustNewConstMetric(upDesc, prometheus.GaugeValue, 1, l...)
+	defer conn.Release()
+
+	ch <- prometheus.MustNewConstMetric(c.upDesc, prometheus.GaugeValue, 1, l...)
+	ch <- prometheus.MustNewConstMetric(c.hostKeyErrorDesc, prometheus.GaugeValue, 0, l...)
+
+	client := rpc.NewClient(conn.Connection(), c.cfg.Debug)
+	client.NoJSON = conn.NoJSON
+	if len(conn.OSType) == 0 {
+		err = client.Identify(ctx)
+		if err != nil {
+			log.Errorln(device.Host + ": " + err.Error())
+			return
+		}
+		conn.OSType = client.OSType
+	} else {
+		client.OSType = conn.OSType
+	}
 
-	client := rpc.NewClient(conn, cfg.Debug)
-	err = client.Identify()
-	if err != nil {
-		log.Errorln(device.Host + ": " + err.Error())
-		return
+	if client.OSType == rpc.IOSXE && client.Restconf == nil && netconfEnabled(device, c.cfg) {
+		client.Netconf, err = netconfSession(ctx, device, conn, c.cfg)
+		if err != nil {
+			log.Errorln(device.Host + ": " + err.Error())
+		}
 	}
 
 	for _, col := range c.collectors.collectorsForDevice(device) {
+		if ctx.Err() != nil {
+			log.Errorln(device.Host + ": " + ctx.Err().Error())
+			return
+		}
+		// RESTCONF provides no CLI to fall back to for collectors without a YANG model supported by the device
+		if client.Restconf != nil && !collector.SupportsOper(col, client) {
+			if c.cfg.Debug {
+				log.Infof("%s: skipping %s, no supported YANG model", device.Host, col.Name())
+			}
+			continue
+		}
+
 		ct := time.Now()
-		err := col.Collect(client, ch, l)
+		err := col.Collect(ctx, client, ch, l)
 
 		if err != nil && err.Error() != "EOF" {
 			log.Errorln(col.Name() + ": " + err.Error())
 		}
 
-		ch <- prometheus.MustNewConstMetric(scrapeCollectorDurationDesc, prometheus.GaugeValue, time.Since(ct).Seconds(), append(l, col.Name())...)
+		ch <- prometheus.MustNewConstMetric(c.scrapeCollectorDurationDesc, prometheus.GaugeValue, time.Since(ct).Seconds(), append(l, col.Name())...)
+	}
+}
+
+func netconfEnabled(device *connector.Device, cfg *config.Config) bool {
+	if device.DeviceConfig.Netconf != nil {
+		return *device.DeviceConfig.Netconf
 	}
+
+	return cfg.Netconf
+}
+
+// netconfSession gets the NETCONF session kept open together with the connection to the device.
+// A new session is started if there is none yet or the existing one is broken
+func netconfSession(ctx context.Context, device *connector.Device, conn *connector.ManagedConnection, cfg *config.Config) (*rpc.NetconfSession, error) {
+	if s, ok := conn.Netconf().(*rpc.NetconfSession); ok && !s.IsBroken() {
+		return s, nil
+	}
+	if conn.Netconf() != nil {
+		conn.Netconf().Close()
+		conn.SetNetconf(nil)
+	}
+
+	nc, err := connector.NewNetconfConnection(ctx, device, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := rpc.NewNetconfSession(ctx, nc, nc.Timeout(), nc.MaxOutputSize())
+	if err != nil {
+		nc.Close()
+		return nil, err
+	}
+	conn.SetNetconf(s)
+
+	return s, nil
 }
@@ -0,0 +1,17 @@
+package collector
+
+import (
+	"github.com/lwlcom/cisco_exporter/rpc"
+)
+
+// OperCollector is implemented by collectors able to get their data from YANG models instead of the CLI
+type OperCollector interface {
+	// SupportsOper checks if the collector can get its data from the models announced by the device
+	SupportsOper(client *rpc.Client) bool
+}
+
+// SupportsOper checks if col can collect without the CLI, e.g. when the device is scraped by RESTCONF
+func SupportsOper(col RPCCollector, client *rpc.Client) bool {
+	oc, ok := col.(OperCollector)
+	return ok && oc.SupportsOper(client)
+}
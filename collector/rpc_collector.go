@@ -1,8 +1,9 @@
 package collector
 
 import (
-	"github.com/lwlcom/cisco_exporter/rpc"
+	"context"
 
+	"github.com/lwlcom/cisco_exporter/rpc"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -15,5 +16,5 @@ type RPCCollector interface {
 	Describe(ch chan<- *prometheus.Desc)
 
 	// Collect collects metrics from Cisco
-	Collect(client *rpc.Client, ch chan<- prometheus.Metric, labelValues []string) error
+	Collect(ctx context.Context, client *rpc.Client, ch chan<- prometheus.Metric, labelValues []string) error
 }
@@ -1,12 +1,15 @@
 package main
 
 import (
+	"sort"
+
 	"github.com/lwlcom/cisco_exporter/bgp"
 	"github.com/lwlcom/cisco_exporter/collector"
 	"github.com/lwlcom/cisco_exporter/config"
 	"github.com/lwlcom/cisco_exporter/connector"
 	"github.com/lwlcom/cisco_exporter/environment"
 	"github.com/lwlcom/cisco_exporter/facts"
+	"github.com/lwlcom/cisco_exporter/firewall"
 	"github.com/lwlcom/cisco_exporter/interfaces"
 	"github.com/lwlcom/cisco_exporter/optics"
 )
@@ -15,6 +18,7 @@ type collectors struct {
 	collectors map[string]collector.RPCCollector
 	devices    map[string][]collector.RPCCollector
 	cfg        *config.Config
+	labelNames []string
 }
 
 func collectorsForDevices(devices []*connector.Device, cfg *config.Config) *collectors {
@@ -22,38 +26,53 @@ func collectorsForDevices(devices []*connector.Device, cfg *config.Config) *coll
 		collectors: make(map[string]collector.RPCCollector),
 		devices:    make(map[string][]collector.RPCCollector),
 		cfg:        cfg,
+		labelNames: labelNamesForDevices(devices),
 	}
 
 	for _, d := range devices {
-		c.initCollectorsForDevice(d)
+		c.initCollectorsForDevice(d, cfg.FeaturesForDevice(d.DeviceConfig.Host))
 	}
 
 	return c
 }
 
-func (c *collectors) initCollectorsForDevice(device *connector.Device) {
-	f := c.cfg.FeaturesForDevice(device.Host)
+func collectorsForProbe(device *connector.Device, features *config.FeatureConfig, cfg *config.Config) *collectors {
+	c := &collectors{
+		collectors: make(map[string]collector.RPCCollector),
+		devices:    make(map[string][]collector.RPCCollector),
+		cfg:        cfg,
+		labelNames: labelNamesForDevices([]*connector.Device{device}),
+	}
+	c.initCollectorsForDevice(device, features)
+
+	return c
+}
 
+func (c *collectors) initCollectorsForDevice(device *connector.Device, f *config.FeatureConfig) {
 	c.devices[device.Host] = make([]collector.RPCCollector, 0)
 	c.addCollectorIfEnabledForDevice(device, "bgp", f.BGP, bgp.NewCollector)
 	c.addCollectorIfEnabledForDevice(device, "environment", f.Environment, environment.NewCollector)
 	c.addCollectorIfEnabledForDevice(device, "facts", f.Facts, facts.NewCollector)
+	c.addCollectorIfEnabledForDevice(device, "firewall", f.Firewall, firewall.NewCollector)
 	c.addCollectorIfEnabledForDevice(device, "interfaces", f.Interfaces, interfaces.NewCollector)
 	c.addCollectorIfEnabledForDevice(device, "optics", f.Optics, optics.NewCollector)
-
 }
 
-func (c *collectors) addCollectorIfEnabledForDevice(device *connector.Device, key string, enabled *bool, newCollector func() collector.RPCCollector) {
-	if !*enabled {
+func (c *collectors) addCollectorIfEnabledForDevice(device *connector.Device, key string, feature *config.Feature, newCollector func(labels []string) collector.RPCCollector) {
+	if !feature.IsEnabled() {
 		return
 	}
 
 	col, found := c.collectors[key]
 	if !found {
-		col = newCollector()
+		col = newCollector(c.labelNames)
 		c.collectors[key] = col
 	}
 
+	if feature.MinInterval() > 0 {
+		col = newCachedCollector(col, feature.MinInterval(), cache)
+	}
+
 	c.devices[device.Host] = append(c.devices[device.Host], col)
 }
 
@@ -77,3 +96,33 @@ func (c *collectors) collectorsForDevice(device *connector.Device) []collector.R
 
 	return cols
 }
+
+// labelNamesForDevices returns the names of the labels identifying a target: target followed by the static labels of all devices
+func labelNamesForDevices(devices []*connector.Device) []string {
+	keys := make(map[string]bool)
+	for _, d := range devices {
+		for k := range d.DeviceConfig.Labels {
+			keys[k] = true
+		}
+	}
+
+	names := make([]string, 0, len(keys))
+	for k := range keys {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	names = append([]string{"target"}, names...)
+	return names[:len(names):len(names)]
+}
+
+// labelValues returns the values of the labels identifying a device in the order of labelNames
+func (c *collectors) labelValues(device *connector.Device) []string {
+	values := make([]string, len(c.labelNames))
+	values[0] = device.Host
+	for i, name := range c.labelNames[1:] {
+		values[i+1] = device.DeviceConfig.Labels[name]
+	}
+
+	return values
+}
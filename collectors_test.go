@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/lwlcom/cisco_exporter/bgp"
+	"github.com/lwlcom/cisco_exporter/collector"
+	"github.com/lwlcom/cisco_exporter/config"
+	"github.com/lwlcom/cisco_exporter/connector"
+	"github.com/lwlcom/cisco_exporter/environment"
+	"github.com/lwlcom/cisco_exporter/facts"
+	"github.com/lwlcom/cisco_exporter/firewall"
+	"github.com/lwlcom/cisco_exporter/interfaces"
+	"github.com/lwlcom/cisco_exporter/optics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// describeOnly registers the descriptions of a collector without collecting
+type describeOnly struct {
+	collector.RPCCollector
+}
+
+func (d describeOnly) Collect(ch chan<- prometheus.Metric) {}
+
+// TestCollectorsWithExampleLabels registers every collector with the static labels of the example config,
+// which fails if one of them is also used as label name by a collector
+func TestCollectorsWithExampleLabels(t *testing.T) {
+	for _, name := range []string{"KEY_PASSPHRASE", "ENABLE_PASSWORD", "PROXY_PASSWORD", "CORE_USERNAME"} {
+		os.Setenv(name, "example")
+		defer os.Unsetenv(name)
+	}
+
+	f, err := os.Open("config.yml.example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	c, err := config.Load(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	devs := make([]*connector.Device, 0)
+	for _, g := range c.Groups {
+		devs = append(devs, &connector.Device{Host: g.Name, DeviceConfig: &config.DeviceConfig{Labels: g.Labels}})
+	}
+	for _, d := range c.Devices {
+		devs = append(devs, &connector.Device{Host: d.Host, DeviceConfig: d})
+	}
+	labelNames := labelNamesForDevices(devs)
+	if len(labelNames) < 2 {
+		t.Fatal("example config has no static labels")
+	}
+
+	constructors := map[string]func([]string) collector.RPCCollector{
+		"bgp":         bgp.NewCollector,
+		"environment": environment.NewCollector,
+		"facts":       facts.NewCollector,
+		"firewall":    firewall.NewCollector,
+		"interfaces":  interfaces.NewCollector,
+		"optics":      optics.NewCollector,
+	}
+	for name, newCollector := range constructors {
+		if err := prometheus.NewRegistry().Register(describeOnly{newCollector(labelNames)}); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+
+	cfg = c
+	limiter = newScrapeLimiter(0)
+	if err := prometheus.NewRegistry().Register(newCiscoCollector(context.Background(), devs)); err != nil {
+		t.Errorf("exporter: %v", err)
+	}
+}
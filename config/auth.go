@@ -0,0 +1,23 @@
+package config
+
+import "fmt"
+
+// Names of the SSH auth methods which can be set in auth_methods
+const (
+	AuthMethodPublicKey           = "publickey"
+	AuthMethodAgent               = "agent"
+	AuthMethodPassword            = "password"
+	AuthMethodKeyboardInteractive = "keyboard-interactive"
+)
+
+func validateAuthMethods(methods []string) error {
+	for _, m := range methods {
+		switch m {
+		case AuthMethodPublicKey, AuthMethodAgent, AuthMethodPassword, AuthMethodKeyboardInteractive:
+		default:
+			return fmt.Errorf("unknown auth method %q", m)
+		}
+	}
+
+	return nil
+}
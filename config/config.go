@@ -1,45 +1,105 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
+	"net/url"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v2"
 )
 
 // Config represents the configuration for the exporter
 type Config struct {
-	Debug         bool            `yaml:"debug"`
-	LegacyCiphers bool            `yaml:"legacy_ciphers,omitempty"`
-	Timeout       int             `yaml:"timeout,omitempty"`
-	BatchSize     int             `yaml:"batch_size,omitempty"`
-	Username      string          `yaml:"username,omitempty"`
-	Password      string          `yaml:"Password,omitempty"`
-	KeyFile       string          `yaml:"key_file,omitempty"`
-	Devices       []*DeviceConfig `yaml:"devices,omitempty"`
-	Features      *FeatureConfig  `yaml:"features,omitempty"`
+	Debug                bool                          `yaml:"debug"`
+	LegacyCiphers        bool                          `yaml:"legacy_ciphers,omitempty"`
+	Timeout              int                           `yaml:"timeout,omitempty"`
+	BatchSize            int                           `yaml:"batch_size,omitempty"`
+	MaxOutputSize        int                           `yaml:"max_output_size,omitempty"`
+	Netconf              bool                          `yaml:"netconf,omitempty"`
+	NetconfPort          int                           `yaml:"netconf_port,omitempty"`
+	Username             string                        `yaml:"username,omitempty"`
+	Password             string                        `yaml:"Password,omitempty"`
+	PasswordFile         string                        `yaml:"password_file,omitempty"`
+	PasswordSecret       string                        `yaml:"password_secret,omitempty"`
+	KeyFile              string                        `yaml:"key_file,omitempty"`
+	KeyPassphrase        string                        `yaml:"key_passphrase,omitempty"`
+	CertificateFile      string                        `yaml:"certificate_file,omitempty"`
+	AgentSocket          string                        `yaml:"agent_socket,omitempty"`
+	AuthMethods          []string                      `yaml:"auth_methods,omitempty"`
+	EnablePassword       string                        `yaml:"enable_password,omitempty"`
+	ProxyJump            []*DeviceConfig               `yaml:"proxy_jump,omitempty"`
+	ProxyURL             string                        `yaml:"proxy_url,omitempty"`
+	TLS                  *TLSConfig                    `yaml:"tls,omitempty"`
+	KnownHostsFile       string                        `yaml:"known_hosts_file,omitempty"`
+	MaxConcurrentScrapes int                           `yaml:"max_concurrent_scrapes,omitempty"`
+	MinLoginInterval     time.Duration                 `yaml:"min_login_interval,omitempty"`
+	IdleTimeout          time.Duration                 `yaml:"idle_timeout,omitempty"`
+	ScrapeInterval       time.Duration                 `yaml:"scrape_interval,omitempty"`
+	TrustOnFirstUse      bool                          `yaml:"trust_on_first_use,omitempty"`
+	FileSDConfigs        []*FileSDConfig               `yaml:"file_sd_configs,omitempty"`
+	HTTPSDConfigs        []*HTTPSDConfig               `yaml:"http_sd_configs,omitempty"`
+	Vault                *VaultConfig                  `yaml:"vault,omitempty"`
+	Credentials          map[string]*CredentialsConfig `yaml:"credentials,omitempty"`
+	Groups               []*GroupConfig                `yaml:"groups,omitempty"`
+	Devices              []*DeviceConfig               `yaml:"devices,omitempty"`
+	Features             *FeatureConfig                `yaml:"features,omitempty"`
+	Modules              map[string]*FeatureConfig     `yaml:"modules,omitempty"`
 }
 
 // DeviceConfig is the config representation of 1 device
 type DeviceConfig struct {
-	Host          string         `yaml:"host"`
-	Username      *string        `yaml:"username,omitempty"`
-	Password      *string        `yaml:"password,omitempty"`
-	KeyFile       *string        `yaml:"key_file,omitempty"`
-	LegacyCiphers *bool          `yaml:"legacy_ciphers,omitempty"`
-	Timeout       *int           `yaml:"timeout,omitempty"`
-	BatchSize     *int           `yaml:"batch_size,omitempty"`
-	Features      *FeatureConfig `yaml:"features,omitempty"`
+	Host                string            `yaml:"host"`
+	Credentials         string            `yaml:"credentials,omitempty"`
+	Username            *string           `yaml:"username,omitempty"`
+	Password            *string           `yaml:"password,omitempty"`
+	PasswordFile        *string           `yaml:"password_file,omitempty"`
+	PasswordSecret      *string           `yaml:"password_secret,omitempty"`
+	KeyFile             *string           `yaml:"key_file,omitempty"`
+	KeyPassphrase       *string           `yaml:"key_passphrase,omitempty"`
+	CertificateFile     *string           `yaml:"certificate_file,omitempty"`
+	AgentSocket         *string           `yaml:"agent_socket,omitempty"`
+	AuthMethods         []string          `yaml:"auth_methods,omitempty"`
+	EnablePassword      *string           `yaml:"enable_password,omitempty"`
+	ProxyJump           []*DeviceConfig   `yaml:"proxy_jump,omitempty"`
+	ProxyURL            *string           `yaml:"proxy_url,omitempty"`
+	Transport           *string           `yaml:"transport,omitempty"`
+	TLS                 *TLSConfig        `yaml:"tls,omitempty"`
+	LegacyCiphers       *bool             `yaml:"legacy_ciphers,omitempty"`
+	Timeout             *int              `yaml:"timeout,omitempty"`
+	BatchSize           *int              `yaml:"batch_size,omitempty"`
+	MaxOutputSize       *int              `yaml:"max_output_size,omitempty"`
+	Netconf             *bool             `yaml:"netconf,omitempty"`
+	NetconfPort         *int              `yaml:"netconf_port,omitempty"`
+	ScrapeInterval      *time.Duration    `yaml:"scrape_interval,omitempty"`
+	HostKeyFingerprints []string          `yaml:"host_key_fingerprints,omitempty"`
+	Features            *FeatureConfig    `yaml:"features,omitempty"`
+	Labels              map[string]string `yaml:"labels,omitempty"`
+}
+
+// FileSDConfig is the config of a file based service discovery in the format used by Prometheus
+type FileSDConfig struct {
+	Files           []string      `yaml:"files"`
+	RefreshInterval time.Duration `yaml:"refresh_interval,omitempty"`
+}
+
+// HTTPSDConfig is the config of a HTTP based service discovery in the format used by Prometheus
+type HTTPSDConfig struct {
+	URL             string        `yaml:"url"`
+	RefreshInterval time.Duration `yaml:"refresh_interval,omitempty"`
 }
 
 // FeatureConfig is the list of collectors enabled or disabled
 type FeatureConfig struct {
-	BGP         *bool `yaml:"bgp,omitempty"`
-	Environment *bool `yaml:"environment,omitempty"`
-	Facts       *bool `yaml:"facts,omitempty"`
-	Interfaces  *bool `yaml:"interfaces,omitempty"`
-	Optics      *bool `yaml:"optics,omitempty"`
+	BGP         *Feature `yaml:"bgp,omitempty"`
+	Environment *Feature `yaml:"environment,omitempty"`
+	Facts       *Feature `yaml:"facts,omitempty"`
+	Firewall    *Feature `yaml:"firewall,omitempty"`
+	Interfaces  *Feature `yaml:"interfaces,omitempty"`
+	Optics      *Feature `yaml:"optics,omitempty"`
 }
 
 // New creates a new config
@@ -65,28 +125,204 @@ func Load(reader io.Reader) (*Config, error) {
 		return nil, err
 	}
 
-	for _, d := range c.Devices {
-		if d.Features == nil {
-			continue
+	for _, m := range c.Modules {
+		m.inherit(c.Features)
+	}
+
+	err = c.expandEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (c *Config) validate() error {
+	for _, sd := range c.FileSDConfigs {
+		if len(sd.Files) == 0 {
+			return errors.New("file_sd_configs: files are missing")
+		}
+	}
+	for _, sd := range c.HTTPSDConfigs {
+		if len(sd.URL) == 0 {
+			return errors.New("http_sd_configs: url is missing")
+		}
+	}
+	if err := validateAuthMethods(c.AuthMethods); err != nil {
+		return err
+	}
+	if err := c.validateProxyJump(c.ProxyJump); err != nil {
+		return err
+	}
+	if err := validateProxyURL(c.ProxyURL); err != nil {
+		return err
+	}
+	if err := c.TLS.validate(); err != nil {
+		return err
+	}
+	if c.Vault != nil && c.Vault.KVVersion != 0 && c.Vault.KVVersion != 1 && c.Vault.KVVersion != 2 {
+		return fmt.Errorf("vault: invalid kv_version %d", c.Vault.KVVersion)
+	}
+
+	for i, g := range c.Groups {
+		if len(g.Name) == 0 {
+			return fmt.Errorf("group %d: name is missing", i+1)
+		}
+		err := g.init()
+		if err != nil {
+			return err
+		}
+		_, err = c.withCredentials(&g.DeviceConfig)
+		if err != nil {
+			return fmt.Errorf("group %s: %v", g.Name, err)
+		}
+		err = validateLabels(g.Labels)
+		if err != nil {
+			return fmt.Errorf("group %s: %v", g.Name, err)
 		}
-		if d.Features.BGP == nil {
-			d.Features.BGP = c.Features.BGP
+		err = validateAuthMethods(g.AuthMethods)
+		if err != nil {
+			return fmt.Errorf("group %s: %v", g.Name, err)
 		}
-		if d.Features.Environment == nil {
-			d.Features.Environment = c.Features.Environment
+		err = c.validateProxyJump(g.ProxyJump)
+		if err != nil {
+			return fmt.Errorf("group %s: %v", g.Name, err)
 		}
-		if d.Features.Facts == nil {
-			d.Features.Facts = c.Features.Facts
+		err = validateDeviceProxyURL(&g.DeviceConfig)
+		if err != nil {
+			return fmt.Errorf("group %s: %v", g.Name, err)
 		}
-		if d.Features.Interfaces == nil {
-			d.Features.Interfaces = c.Features.Interfaces
+		err = validateTransport(&g.DeviceConfig)
+		if err != nil {
+			return fmt.Errorf("group %s: %v", g.Name, err)
 		}
-		if d.Features.Optics == nil {
-			d.Features.Optics = c.Features.Optics
+		err = g.TLS.validate()
+		if err != nil {
+			return fmt.Errorf("group %s: %v", g.Name, err)
 		}
 	}
 
-	return c, nil
+	hosts := make(map[string]bool)
+	for i, d := range c.Devices {
+		if len(d.Host) == 0 {
+			return fmt.Errorf("device %d: host is missing", i+1)
+		}
+		if hosts[d.Host] {
+			return fmt.Errorf("device %s: host is configured more than once", d.Host)
+		}
+		hosts[d.Host] = true
+
+		err := c.ValidateDevice(d)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ValidateDevice validates the config of a device, e.g. one found by service discovery
+func (c *Config) ValidateDevice(d *DeviceConfig) error {
+	if len(d.Host) == 0 {
+		return errors.New("device: host is missing")
+	}
+
+	_, err := c.withCredentials(d)
+	if err != nil {
+		return fmt.Errorf("device %s: %v", d.Host, err)
+	}
+	err = validateLabels(d.Labels)
+	if err != nil {
+		return fmt.Errorf("device %s: %v", d.Host, err)
+	}
+	err = validateAuthMethods(d.AuthMethods)
+	if err != nil {
+		return fmt.Errorf("device %s: %v", d.Host, err)
+	}
+	err = c.validateProxyJump(d.ProxyJump)
+	if err != nil {
+		return fmt.Errorf("device %s: %v", d.Host, err)
+	}
+	err = validateDeviceProxyURL(d)
+	if err != nil {
+		return fmt.Errorf("device %s: %v", d.Host, err)
+	}
+	err = validateTransport(d)
+	if err != nil {
+		return fmt.Errorf("device %s: %v", d.Host, err)
+	}
+	err = d.TLS.validate()
+	if err != nil {
+		return fmt.Errorf("device %s: %v", d.Host, err)
+	}
+
+	return nil
+}
+
+func (c *Config) validateProxyJump(hops []*DeviceConfig) error {
+	for i, h := range hops {
+		if len(h.Host) == 0 {
+			return fmt.Errorf("proxy_jump %d: host is missing", i+1)
+		}
+		if len(h.ProxyJump) > 0 {
+			return fmt.Errorf("proxy_jump %s: hops must not have a proxy_jump, add all hops in order instead", h.Host)
+		}
+		if _, err := c.withCredentials(h); err != nil {
+			return fmt.Errorf("proxy_jump %s: %v", h.Host, err)
+		}
+		if err := validateAuthMethods(h.AuthMethods); err != nil {
+			return fmt.Errorf("proxy_jump %s: %v", h.Host, err)
+		}
+		if err := validateDeviceProxyURL(h); err != nil {
+			return fmt.Errorf("proxy_jump %s: %v", h.Host, err)
+		}
+		if h.TransportOrDefault() != TransportSSH {
+			return fmt.Errorf("proxy_jump %s: hops can only be reached by ssh", h.Host)
+		}
+	}
+
+	return nil
+}
+
+func validateDeviceProxyURL(d *DeviceConfig) error {
+	if d.ProxyURL == nil {
+		return nil
+	}
+
+	return validateProxyURL(*d.ProxyURL)
+}
+
+func validateProxyURL(proxyURL string) error {
+	if len(proxyURL) == 0 {
+		return nil
+	}
+
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return errors.New("invalid proxy_url")
+	}
+
+	switch u.Scheme {
+	case "socks5", "socks5h", "http":
+	default:
+		return fmt.Errorf("invalid proxy_url: unsupported scheme %q", u.Scheme)
+	}
+
+	return nil
+}
+
+func (f *FeatureConfig) inherit(parent *FeatureConfig) {
+	f.BGP = f.BGP.inherit(parent.BGP)
+	f.Environment = f.Environment.inherit(parent.Environment)
+	f.Facts = f.Facts.inherit(parent.Facts)
+	f.Firewall = f.Firewall.inherit(parent.Firewall)
+	f.Interfaces = f.Interfaces.inherit(parent.Interfaces)
+	f.Optics = f.Optics.inherit(parent.Optics)
 }
 
 func (c *Config) setDefaultValues() {
@@ -94,18 +330,17 @@ func (c *Config) setDefaultValues() {
 	c.LegacyCiphers = false
 	c.Timeout = 5
 	c.BatchSize = 10000
+	c.MaxOutputSize = 10 * 1024 * 1024
+	c.NetconfPort = 830
+	c.IdleTimeout = 10 * time.Minute
 
 	f := c.Features
-	bgp := true
-	f.BGP = &bgp
-	environment := true
-	f.Environment = &environment
-	facts := true
-	f.Facts = &facts
-	interfaces := true
-	f.Interfaces = &interfaces
-	optics := true
-	f.Optics = &optics
+	f.BGP = NewFeature(true)
+	f.Environment = NewFeature(true)
+	f.Facts = NewFeature(true)
+	f.Firewall = NewFeature(true)
+	f.Interfaces = NewFeature(true)
+	f.Optics = NewFeature(true)
 }
 
 // DevicesFromTargets creates devices configs from targets list
@@ -120,17 +355,64 @@ func (c *Config) DevicesFromTargets(sshHosts string) {
 	}
 }
 
+// Copy creates a shallow copy of the config with its own list of devices
+func (c *Config) Copy() *Config {
+	cp := *c
+	cp.Devices = append([]*DeviceConfig{}, c.Devices...)
+
+	return &cp
+}
+
+// AddDevice adds a device config. Devices already configured are not added again
+func (c *Config) AddDevice(device *DeviceConfig) bool {
+	if c.findDeviceConfig(device.Host) != nil {
+		return false
+	}
+
+	c.Devices = append(c.Devices, device)
+
+	return true
+}
+
 // FeaturesForDevice gets the feature set configured for a device
 func (c *Config) FeaturesForDevice(host string) *FeatureConfig {
-	d := c.findDeviceConfig(host)
+	d, err := c.ResolveDevice(c.DeviceConfigForTarget(host))
 
-	if d != nil && d.Features != nil {
+	if err == nil && d.Features != nil {
 		return d.Features
 	}
 
 	return c.Features
 }
 
+// FeaturesForProbe gets the feature set for a probe of host. If a module is given, its
+// feature set is used instead of the one configured for the device
+func (c *Config) FeaturesForProbe(host, module string) (*FeatureConfig, error) {
+	if len(module) == 0 {
+		return c.FeaturesForDevice(host), nil
+	}
+
+	f, found := c.Modules[module]
+	if !found {
+		return nil, fmt.Errorf("unknown module %q", module)
+	}
+
+	return f, nil
+}
+
+// DeviceConfigForTarget gets the config for a device by its host. Targets without
+// a config entry get an empty one, so they are scraped using the global settings
+func (c *Config) DeviceConfigForTarget(target string) *DeviceConfig {
+	d := c.findDeviceConfig(target)
+	if d != nil {
+		return d
+	}
+
+	return &DeviceConfig{
+		Host: target,
+	}
+}
+
 func (c *Config) findDeviceConfig(host string) *DeviceConfig {
 	for _, dc := range c.Devices {
 		if dc.Host == host {
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Feature is the config of one collector. Setting it to a bool only enables or disables the collector
+type Feature struct {
+	Enabled  *bool          `yaml:"enabled,omitempty"`
+	Interval *time.Duration `yaml:"interval,omitempty"`
+}
+
+// NewFeature creates a new feature config
+func NewFeature(enabled bool) *Feature {
+	return &Feature{Enabled: &enabled}
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler interface
+func (f *Feature) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var enabled bool
+	if err := unmarshal(&enabled); err == nil {
+		f.Enabled = &enabled
+		return nil
+	}
+
+	type plain Feature
+	return unmarshal((*plain)(f))
+}
+
+// IsEnabled returns if the collector is enabled
+func (f *Feature) IsEnabled() bool {
+	return f != nil && f.Enabled != nil && *f.Enabled
+}
+
+// MinInterval returns the minimum interval between two runs of the collector (0 = run on every scrape)
+func (f *Feature) MinInterval() time.Duration {
+	if f == nil || f.Interval == nil {
+		return 0
+	}
+
+	return *f.Interval
+}
+
+func (f *Feature) inherit(parent *Feature) *Feature {
+	if f == nil {
+		return parent
+	}
+	if parent == nil {
+		return f
+	}
+
+	merged := *f
+	if merged.Enabled == nil {
+		merged.Enabled = parent.Enabled
+	}
+	if merged.Interval == nil {
+		merged.Interval = parent.Interval
+	}
+
+	return &merged
+}
+
+// SetFeature sets the config of a feature by its name
+func (f *FeatureConfig) SetFeature(name string, feature *Feature) error {
+	switch name {
+	case "bgp":
+		f.BGP = feature
+	case "environment":
+		f.Environment = feature
+	case "facts":
+		f.Facts = feature
+	case "firewall":
+		f.Firewall = feature
+	case "interfaces":
+		f.Interfaces = feature
+	case "optics":
+		f.Optics = feature
+	default:
+		return fmt.Errorf("unknown feature %q", name)
+	}
+
+	return nil
+}
@@ -0,0 +1,176 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"regexp"
+)
+
+// CredentialsConfig is a named set of credentials devices and groups can refer to
+type CredentialsConfig struct {
+	Username        *string `yaml:"username,omitempty"`
+	Password        *string `yaml:"password,omitempty"`
+	PasswordFile    *string `yaml:"password_file,omitempty"`
+	PasswordSecret  *string `yaml:"password_secret,omitempty"`
+	KeyFile         *string `yaml:"key_file,omitempty"`
+	KeyPassphrase   *string `yaml:"key_passphrase,omitempty"`
+	CertificateFile *string `yaml:"certificate_file,omitempty"`
+	EnablePassword  *string `yaml:"enable_password,omitempty"`
+}
+
+// GroupConfig is the config shared by all devices matching one of the host patterns or the host regex.
+// A device belongs to the first matching group
+type GroupConfig struct {
+	Name         string   `yaml:"name"`
+	Hosts        []string `yaml:"hosts,omitempty"`
+	HostRegex    string   `yaml:"host_regex,omitempty"`
+	DeviceConfig `yaml:",inline"`
+
+	hostRegexp *regexp.Regexp
+}
+
+func (g *GroupConfig) init() error {
+	for _, pattern := range g.Hosts {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("group %s: invalid host pattern %q", g.Name, pattern)
+		}
+	}
+
+	if len(g.HostRegex) > 0 {
+		re, err := regexp.Compile(g.HostRegex)
+		if err != nil {
+			return fmt.Errorf("group %s: invalid host regex: %v", g.Name, err)
+		}
+		g.hostRegexp = re
+	}
+
+	return nil
+}
+
+func (g *GroupConfig) matches(host string) bool {
+	for _, pattern := range g.Hosts {
+		if matched, _ := path.Match(pattern, host); matched {
+			return true
+		}
+	}
+
+	return g.hostRegexp != nil && g.hostRegexp.MatchString(host)
+}
+
+// ResolveProxyJump creates the effective config of the hops to reach a device, which is either set for the device
+// (or its group) or globally. Hops get their credentials from their credentials profile and the global settings
+func (c *Config) ResolveProxyJump(device *DeviceConfig) ([]*DeviceConfig, error) {
+	hops := c.ProxyJump
+	if device.ProxyJump != nil {
+		hops = device.ProxyJump
+	}
+
+	resolved := make([]*DeviceConfig, len(hops))
+	for i, h := range hops {
+		hop, err := c.withCredentials(h)
+		if err != nil {
+			return nil, fmt.Errorf("proxy_jump %s: %v", h.Host, err)
+		}
+		resolved[i] = hop
+	}
+
+	return resolved, nil
+}
+
+// GroupForHost gets the group a device belongs to
+func (c *Config) GroupForHost(host string) *GroupConfig {
+	for _, g := range c.Groups {
+		if g.matches(host) {
+			return g
+		}
+	}
+
+	return nil
+}
+
+// ResolveDevice creates the effective config of a device. Settings not set for the device are taken from its
+// credentials profile, then from its group and the credentials profile of the group. Global settings apply to
+// everything still unset afterwards
+func (c *Config) ResolveDevice(device *DeviceConfig) (*DeviceConfig, error) {
+	d, err := c.withCredentials(device)
+	if err != nil {
+		return nil, fmt.Errorf("device %s: %v", device.Host, err)
+	}
+
+	if g := c.GroupForHost(device.Host); g != nil {
+		gd, err := c.withCredentials(&g.DeviceConfig)
+		if err != nil {
+			return nil, fmt.Errorf("group %s: %v", g.Name, err)
+		}
+		d = mergeDeviceConfig(d, gd)
+	}
+
+	if err := validateLabels(d.Labels); err != nil {
+		return nil, fmt.Errorf("device %s: %v", device.Host, err)
+	}
+
+	if d.Features != nil {
+		f := *d.Features
+		f.inherit(c.Features)
+		d.Features = &f
+	}
+
+	return d, nil
+}
+
+func (c *Config) withCredentials(d *DeviceConfig) (*DeviceConfig, error) {
+	if len(d.Credentials) == 0 {
+		cp := *d
+		return &cp, nil
+	}
+
+	p, found := c.Credentials[d.Credentials]
+	if !found {
+		return nil, fmt.Errorf("unknown credentials profile %q", d.Credentials)
+	}
+
+	return mergeDeviceConfig(d, &DeviceConfig{
+		Username:        p.Username,
+		Password:        p.Password,
+		PasswordFile:    p.PasswordFile,
+		PasswordSecret:  p.PasswordSecret,
+		KeyFile:         p.KeyFile,
+		KeyPassphrase:   p.KeyPassphrase,
+		CertificateFile: p.CertificateFile,
+		EnablePassword:  p.EnablePassword,
+	}), nil
+}
+
+// mergeDeviceConfig returns a copy of d with all unset optional settings taken from parent.
+// The password is only taken from parent if d has neither a password, a password file nor a password secret
+func mergeDeviceConfig(d, parent *DeviceConfig) *DeviceConfig {
+	merged := *d
+
+	if d.hasPassword() {
+		p := *parent
+		p.Password, p.PasswordFile, p.PasswordSecret = nil, nil, nil
+		parent = &p
+	}
+
+	if merged.Features != nil && parent.Features != nil {
+		f := *merged.Features
+		f.inherit(parent.Features)
+		merged.Features = &f
+	}
+	merged.Labels = mergeLabels(merged.Labels, parent.Labels)
+
+	mv := reflect.ValueOf(&merged).Elem()
+	pv := reflect.ValueOf(parent).Elem()
+	for i := 0; i < mv.NumField(); i++ {
+		f := mv.Field(i)
+		switch f.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Map:
+			if f.IsNil() {
+				f.Set(pv.Field(i))
+			}
+		}
+	}
+
+	return &merged
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var labelNameRegexp = regexp.MustCompile("^[a-zA-Z_][a-zA-Z0-9_]*$")
+
+// reservedLabelNames are the label names used by the exporter itself
+var reservedLabelNames = map[string]bool{
+	"asn":             true,
+	"collector":       true,
+	"config_register": true,
+	"description":     true,
+	"failover_peer":   true,
+	"failover_role":   true,
+	"failover_state":  true,
+	"hostname":        true,
+	"image":           true,
+	"interface":       true,
+	"ip":              true,
+	"item":            true,
+	"mac":             true,
+	"model":           true,
+	"name":            true,
+	"reason":          true,
+	"serial":          true,
+	"speed":           true,
+	"status":          true,
+	"target":          true,
+	"type":            true,
+	"version":         true,
+}
+
+func validateLabels(labels map[string]string) error {
+	for name := range labels {
+		if !labelNameRegexp.MatchString(name) || strings.HasPrefix(name, "__") {
+			return fmt.Errorf("invalid label name %q", name)
+		}
+		if reservedLabelNames[name] {
+			return fmt.Errorf("label name %q is reserved", name)
+		}
+	}
+
+	return nil
+}
+
+// mergeLabels returns the labels of parent overridden by labels
+func mergeLabels(labels, parent map[string]string) map[string]string {
+	if len(parent) == 0 {
+		return labels
+	}
+	if len(labels) == 0 {
+		return parent
+	}
+
+	merged := make(map[string]string, len(labels)+len(parent))
+	for k, v := range parent {
+		merged[k] = v
+	}
+	for k, v := range labels {
+		merged[k] = v
+	}
+
+	return merged
+}
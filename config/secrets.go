@@ -0,0 +1,102 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+)
+
+var envRegexp = regexp.MustCompile(`\$\{([a-zA-Z_][a-zA-Z0-9_]*)\}`)
+
+// VaultConfig is the config of the HashiCorp Vault KV secrets engine used to read secrets
+type VaultConfig struct {
+	Address   string `yaml:"address,omitempty"`
+	Token     string `yaml:"token,omitempty"`
+	TokenFile string `yaml:"token_file,omitempty"`
+	Namespace string `yaml:"namespace,omitempty"`
+	Mount     string `yaml:"mount,omitempty"`
+	KVVersion int    `yaml:"kv_version,omitempty"`
+}
+
+func (d *DeviceConfig) hasPassword() bool {
+	return d.Password != nil || d.PasswordFile != nil || d.PasswordSecret != nil
+}
+
+// expandEnv replaces ${VAR} in all credentials settings (including the proxy URL) by the value of the environment variable VAR
+func (c *Config) expandEnv() error {
+	for _, s := range []*string{&c.Username, &c.Password, &c.PasswordFile, &c.PasswordSecret, &c.KeyFile, &c.KeyPassphrase, &c.CertificateFile, &c.ProxyURL, &c.EnablePassword} {
+		if err := expandEnvString(s); err != nil {
+			return err
+		}
+	}
+
+	if c.Vault != nil {
+		for _, s := range []*string{&c.Vault.Address, &c.Vault.Token, &c.Vault.TokenFile} {
+			if err := expandEnvString(s); err != nil {
+				return fmt.Errorf("vault: %v", err)
+			}
+		}
+	}
+
+	for name, p := range c.Credentials {
+		for _, s := range []*string{p.Username, p.Password, p.PasswordFile, p.PasswordSecret, p.KeyFile, p.KeyPassphrase, p.CertificateFile, p.EnablePassword} {
+			if err := expandEnvString(s); err != nil {
+				return fmt.Errorf("credentials %s: %v", name, err)
+			}
+		}
+	}
+
+	for _, h := range c.ProxyJump {
+		if err := h.expandEnv(); err != nil {
+			return fmt.Errorf("proxy_jump %s: %v", h.Host, err)
+		}
+	}
+
+	for _, g := range c.Groups {
+		if err := g.DeviceConfig.expandEnv(); err != nil {
+			return fmt.Errorf("group %s: %v", g.Name, err)
+		}
+	}
+
+	for _, d := range c.Devices {
+		if err := d.expandEnv(); err != nil {
+			return fmt.Errorf("device %s: %v", d.Host, err)
+		}
+	}
+
+	return nil
+}
+
+func (d *DeviceConfig) expandEnv() error {
+	for _, s := range []*string{d.Username, d.Password, d.PasswordFile, d.PasswordSecret, d.KeyFile, d.KeyPassphrase, d.CertificateFile, d.ProxyURL, d.EnablePassword} {
+		if err := expandEnvString(s); err != nil {
+			return err
+		}
+	}
+
+	for _, h := range d.ProxyJump {
+		if err := h.expandEnv(); err != nil {
+			return fmt.Errorf("proxy_jump %s: %v", h.Host, err)
+		}
+	}
+
+	return nil
+}
+
+func expandEnvString(s *string) error {
+	if s == nil {
+		return nil
+	}
+
+	var err error
+	*s = envRegexp.ReplaceAllStringFunc(*s, func(m string) string {
+		name := envRegexp.FindStringSubmatch(m)[1]
+		v, found := os.LookupEnv(name)
+		if !found && err == nil {
+			err = fmt.Errorf("environment variable %s is not set", name)
+		}
+		return v
+	})
+
+	return err
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Names of the transports which can be set in transport
+const (
+	TransportSSH      = "ssh"
+	TransportTelnet   = "telnet"
+	TransportRestconf = "restconf"
+)
+
+// TLSConfig is the config of TLS connections to devices
+type TLSConfig struct {
+	CAFile             string `yaml:"ca_file,omitempty"`
+	CertFile           string `yaml:"cert_file,omitempty"`
+	KeyFile            string `yaml:"key_file,omitempty"`
+	ServerName         string `yaml:"server_name,omitempty"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify,omitempty"`
+}
+
+// TransportOrDefault gets the transport used to connect to the device, SSH if none is set
+func (d *DeviceConfig) TransportOrDefault() string {
+	if d.Transport == nil || len(*d.Transport) == 0 {
+		return TransportSSH
+	}
+
+	return *d.Transport
+}
+
+func validateTransport(d *DeviceConfig) error {
+	switch d.TransportOrDefault() {
+	case TransportSSH, TransportTelnet, TransportRestconf:
+		return nil
+	default:
+		return fmt.Errorf("unknown transport %q", *d.Transport)
+	}
+}
+
+func (t *TLSConfig) validate() error {
+	if t == nil {
+		return nil
+	}
+
+	if (len(t.CertFile) == 0) != (len(t.KeyFile) == 0) {
+		return errors.New("tls: cert_file and key_file have to be set together")
+	}
+
+	return nil
+}
@@ -0,0 +1,226 @@
+package connector
+
+import (
+	"io"
+	"io/ioutil"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// AuthMethod is the method to use to authenticate agaist the device
+type AuthMethod func(*authConfig)
+
+// authConfig collects the SSH auth methods in the order they are tried.
+// All public key based methods (keys, certificates and agent) are tried as one method at the position
+// of the first of them, because the SSH client tries every method type only once
+type authConfig struct {
+	user           string
+	password       string
+	methods        []ssh.AuthMethod
+	signers        []func() ([]ssh.Signer, error)
+	publicKeyIndex int
+}
+
+func (a AuthMethod) apply(cfg *ssh.ClientConfig) {
+	c := &authConfig{publicKeyIndex: -1}
+	a(c)
+
+	cfg.User = c.user
+	cfg.Auth = c.methods
+	if c.publicKeyIndex >= 0 {
+		cfg.Auth = append(cfg.Auth[:c.publicKeyIndex:c.publicKeyIndex], ssh.PublicKeysCallback(c.publicKeySigners))
+		cfg.Auth = append(cfg.Auth, c.methods[c.publicKeyIndex:]...)
+	}
+}
+
+// credentials gets username and password for transports only supporting password authentication
+func (a AuthMethod) credentials() (string, string) {
+	c := &authConfig{publicKeyIndex: -1}
+	a(c)
+
+	return c.user, c.password
+}
+
+func (c *authConfig) addSigners(signers func() ([]ssh.Signer, error)) {
+	if c.publicKeyIndex < 0 {
+		c.publicKeyIndex = len(c.methods)
+	}
+	c.signers = append(c.signers, signers)
+}
+
+func (c *authConfig) publicKeySigners() ([]ssh.Signer, error) {
+	signers := make([]ssh.Signer, 0)
+	var lastErr error
+	for _, f := range c.signers {
+		s, err := f()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		signers = append(signers, s...)
+	}
+
+	if len(signers) == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+
+	return signers, nil
+}
+
+// AuthMethods tries the given methods in order until one succeeds
+func AuthMethods(methods ...AuthMethod) AuthMethod {
+	return func(c *authConfig) {
+		for _, m := range methods {
+			m(c)
+		}
+	}
+}
+
+// AuthByPassword uses password authentication
+func AuthByPassword(username, password string) AuthMethod {
+	return func(c *authConfig) {
+		c.user = username
+		c.password = password
+		c.methods = append(c.methods, ssh.Password(password))
+	}
+}
+
+// AuthByKeyboardInteractive uses keyboard-interactive authentication (e.g. for devices using TACACS+).
+// Questions asking for the username are answered with the username, all other questions with the password
+func AuthByKeyboardInteractive(username, password string) AuthMethod {
+	challenge := func(user, instruction string, questions []string, echos []bool) ([]string, error) {
+		answers := make([]string, len(questions))
+		for i, q := range questions {
+			if echos[i] && strings.Contains(strings.ToLower(q), "user") {
+				answers[i] = username
+			} else {
+				answers[i] = password
+			}
+		}
+		return answers, nil
+	}
+
+	return func(c *authConfig) {
+		c.user = username
+		c.password = password
+		c.methods = append(c.methods, ssh.KeyboardInteractive(challenge))
+	}
+}
+
+// AuthByKey uses public key authentication
+func AuthByKey(username string, key io.Reader) (AuthMethod, error) {
+	return AuthByEncryptedKey(username, key, "")
+}
+
+// AuthByEncryptedKey uses public key authentication with a passphrase protected private key
+func AuthByEncryptedKey(username string, key io.Reader, passphrase string) (AuthMethod, error) {
+	signer, err := loadPrivateKey(key, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	return authBySigner(username, signer), nil
+}
+
+// AuthByCertificate uses public key authentication with an OpenSSH user certificate.
+// passphrase is only used if the private key is protected by a passphrase
+func AuthByCertificate(username string, key io.Reader, passphrase string, cert io.Reader) (AuthMethod, error) {
+	signer, err := loadPrivateKey(key, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := ioutil.ReadAll(cert)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read from reader")
+	}
+
+	pk, _, _, _, err := ssh.ParseAuthorizedKey(b)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse certificate")
+	}
+
+	c, ok := pk.(*ssh.Certificate)
+	if !ok {
+		return nil, errors.New("could not parse certificate: not an OpenSSH certificate")
+	}
+
+	certSigner, err := ssh.NewCertSigner(c, signer)
+	if err != nil {
+		return nil, errors.Wrap(err, "certificate does not match private key")
+	}
+
+	return authBySigner(username, certSigner), nil
+}
+
+func authBySigner(username string, signer ssh.Signer) AuthMethod {
+	return func(c *authConfig) {
+		c.user = username
+		c.addSigners(func() ([]ssh.Signer, error) {
+			return []ssh.Signer{signer}, nil
+		})
+	}
+}
+
+// AuthByAgent uses public key authentication with the keys of the SSH agent listening on socket
+func AuthByAgent(username, socket string) AuthMethod {
+	a := &agentSigners{socket: socket}
+
+	return func(c *authConfig) {
+		c.user = username
+		c.addSigners(a.signers)
+	}
+}
+
+// agentSigners keeps the connection to the agent open until the next login, since signing uses the agent
+type agentSigners struct {
+	socket string
+	conn   net.Conn
+	mu     sync.Mutex
+}
+
+func (a *agentSigners) signers() ([]ssh.Signer, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.conn != nil {
+		a.conn.Close()
+		a.conn = nil
+	}
+
+	conn, err := net.Dial("unix", a.socket)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not connect to ssh agent")
+	}
+	a.conn = conn
+
+	return agent.NewClient(conn).Signers()
+}
+
+func loadPrivateKey(r io.Reader, passphrase string) (ssh.Signer, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read from reader")
+	}
+
+	var key ssh.Signer
+	if len(passphrase) > 0 {
+		key, err = ssh.ParsePrivateKeyWithPassphrase(b, []byte(passphrase))
+	} else {
+		key, err = ssh.ParsePrivateKey(b)
+	}
+	if err != nil {
+		var missing *ssh.PassphraseMissingError
+		if errors.As(err, &missing) {
+			return nil, errors.New("could not parse private key: key is protected by a passphrase")
+		}
+		return nil, errors.Wrap(err, "could not parse private key")
+	}
+
+	return key, nil
+}
@@ -1,78 +1,112 @@
 package connector
 
 import (
-	"bufio"
-	"io"
-	"io/ioutil"
-	"regexp"
-	"strings"
-
+	"context"
+	"net"
 	"time"
 
 	"github.com/lwlcom/cisco_exporter/config"
-	"github.com/pkg/errors"
 	"golang.org/x/crypto/ssh"
 )
 
 // NewSSSHConnection connects to device
-func NewSSSHConnection(device *Device, cfg *config.Config) (*SSHConnection, error) {
-	deviceConfig := device.DeviceConfig
+func NewSSSHConnection(ctx context.Context, device *Device, cfg *config.Config) (*SSHConnection, error) {
+	var hostKeyErr error
+	c := &SSHConnection{
+		host:         device.Host + ":" + device.Port,
+		shellConfig:  shellConfigForDevice(device, cfg),
+		clientConfig: sshClientConfig(device, cfg, &hostKeyErr),
+	}
 
-	legacyCiphers := cfg.LegacyCiphers
-	if deviceConfig.LegacyCiphers != nil {
-		legacyCiphers = *deviceConfig.LegacyCiphers
+	var err error
+	c.dial, c.jumpClient, err = dialForDevice(ctx, device, cfg)
+	if err != nil {
+		return nil, err
 	}
 
-	batchSize := cfg.BatchSize
-	if deviceConfig.BatchSize != nil {
-		batchSize = *deviceConfig.BatchSize
+	err = c.Connect(ctx)
+	if hostKeyErr != nil {
+		c.Close()
+		return nil, hostKeyErr
 	}
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// sshClientConfig creates the SSH client config for a device. hostKeyErr is set if the host key could not be verified
+func sshClientConfig(device *Device, cfg *config.Config, hostKeyErr *error) *ssh.ClientConfig {
+	deviceConfig := device.DeviceConfig
 
-	timeout := cfg.Timeout
-	if deviceConfig.Timeout != nil {
-		timeout = *deviceConfig.Timeout
+	legacyCiphers := cfg.LegacyCiphers
+	if deviceConfig.LegacyCiphers != nil {
+		legacyCiphers = *deviceConfig.LegacyCiphers
 	}
 
+	verifyHostKey := hostKeyCallback(device, cfg)
 	sshConfig := &ssh.ClientConfig{
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
-		Timeout:         time.Duration(timeout) * time.Second,
+		HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			*hostKeyErr = verifyHostKey(hostname, remote, key)
+			return *hostKeyErr
+		},
+		Timeout: timeoutForDevice(device, cfg),
 	}
 	if legacyCiphers {
 		sshConfig.SetDefaults()
 		sshConfig.Ciphers = append(sshConfig.Ciphers, "aes128-cbc", "3des-cbc")
 	}
 
-	device.Auth(sshConfig)
+	device.Auth.apply(sshConfig)
 
-	c := &SSHConnection{
-		Host:         device.Host + ":" + device.Port,
-		batchSize:    batchSize,
-		clientConfig: sshConfig,
-	}
+	return sshConfig
+}
+
+// newClient establishes an SSH connection over conn. conn is closed if the context is done before the handshake completed
+func newClient(ctx context.Context, conn net.Conn, addr string, sshConfig *ssh.ClientConfig) (*ssh.Client, error) {
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
 
-	err := c.Connect()
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, addr, sshConfig)
 	if err != nil {
+		conn.Close()
 		return nil, err
 	}
 
-	return c, nil
+	return ssh.NewClient(sshConn, chans, reqs), nil
 }
 
 // SSHConnection encapsulates the connection to the device
 type SSHConnection struct {
 	client       *ssh.Client
-	Host         string
-	stdin        io.WriteCloser
-	stdout       io.Reader
+	host         string
 	session      *ssh.Session
-	batchSize    int
+	shell        *shell
+	shellConfig  shellConfig
 	clientConfig *ssh.ClientConfig
+	dial         dialFunc
+	jumpClient   *jumpClient
 }
 
 // Connect connects to the device
-func (c *SSHConnection) Connect() error {
-	var err error
-	c.client, err = ssh.Dial("tcp", c.Host, c.clientConfig)
+func (c *SSHConnection) Connect(ctx context.Context) error {
+	dialCtx, cancel := context.WithTimeout(ctx, c.clientConfig.Timeout)
+	conn, err := c.dial(dialCtx, "tcp", c.host)
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	c.client, err = newClient(ctx, conn, c.host, c.clientConfig)
 	if err != nil {
 		return err
 	}
@@ -82,8 +116,8 @@ func (c *SSHConnection) Connect() error {
 		c.client.Conn.Close()
 		return err
 	}
-	c.stdin, _ = session.StdinPipe()
-	c.stdout, _ = session.StdoutPipe()
+	stdin, _ := session.StdinPipe()
+	stdout, _ := session.StdoutPipe()
 	modes := ssh.TerminalModes{
 		ssh.ECHO:  0,
 		ssh.OCRNL: 0,
@@ -92,37 +126,29 @@ func (c *SSHConnection) Connect() error {
 	session.Shell()
 	c.session = session
 
-	c.RunCommand("")
-	c.RunCommand("terminal length 0")
-
-	return nil
+	c.shell = newShell(c.host, stdin, stdout, c.shellConfig, c.clientConfig.Timeout, c.Close)
+	return c.shell.start(ctx)
 }
 
-type result struct {
-	output string
-	err    error
+// Host returns the address of the device
+func (c *SSHConnection) Host() string {
+	return c.host
 }
 
-// RunCommand runs a command against the device
-func (c *SSHConnection) RunCommand(cmd string) (string, error) {
-	buf := bufio.NewReader(c.stdout)
-	io.WriteString(c.stdin, cmd+"\n")
-
-	outputChan := make(chan result)
-	go func() {
-		c.readln(outputChan, cmd, buf)
-	}()
-	select {
-	case res := <-outputChan:
-		return res.output, res.err
-	case <-time.After(c.clientConfig.Timeout):
-		return "", errors.New("Timeout reached")
-	}
+// RunCommand runs a command against the device. If the context is done or the timeout is reached
+// before the command completed, the connection is closed as the shell is in an unknown state afterwards
+func (c *SSHConnection) RunCommand(ctx context.Context, cmd string) (string, error) {
+	return c.shell.run(ctx, cmd)
 }
 
 // Close closes connection
 func (c *SSHConnection) Close() {
-	if c.client.Conn == nil {
+	if c.jumpClient != nil {
+		jumpClients.release(c.jumpClient)
+		c.jumpClient = nil
+	}
+
+	if c.client == nil || c.client.Conn == nil {
 		return
 	}
 	c.client.Conn.Close()
@@ -131,34 +157,21 @@ func (c *SSHConnection) Close() {
 	}
 }
 
-func loadPrivateKey(r io.Reader) (ssh.AuthMethod, error) {
-	b, err := ioutil.ReadAll(r)
-	if err != nil {
-		return nil, errors.Wrap(err, "could not read from reader")
+// isAlive checks if the connection can still be used for further commands
+func (c *SSHConnection) isAlive() bool {
+	if c.shell == nil || c.shell.broken {
+		return false
 	}
 
-	key, err := ssh.ParsePrivateKey(b)
-	if err != nil {
-		return nil, errors.Wrap(err, "could not parse private key")
-	}
-
-	return ssh.PublicKeys(key), nil
-}
-
-func (c *SSHConnection) readln(ch chan result, cmd string, r io.Reader) {
-	re := regexp.MustCompile(`.+#\s?$`)
-	buf := make([]byte, c.batchSize)
-	loadStr := ""
-	for {
-		n, err := r.Read(buf)
-		if err != nil {
-			ch <- result{output: "", err: err}
-		}
-		loadStr += string(buf[:n])
-		if strings.Contains(loadStr, cmd) && re.MatchString(loadStr) {
-			break
-		}
+	errChan := make(chan error, 1)
+	go func() {
+		_, _, err := c.client.SendRequest("keepalive@openssh.com", true, nil)
+		errChan <- err
+	}()
+	select {
+	case err := <-errChan:
+		return err == nil
+	case <-time.After(c.clientConfig.Timeout):
+		return false
 	}
-	loadStr = strings.Replace(loadStr, "\r", "", -1)
-	ch <- result{output: loadStr, err: nil}
 }
@@ -0,0 +1,271 @@
+package connector
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/lwlcom/cisco_exporter/config"
+)
+
+// idleCheckInterval is the interval in which connections are checked for the idle timeout
+const idleCheckInterval = 30 * time.Second
+
+// ConnectionManager keeps one connection per device open and reuses it across scrapes
+type ConnectionManager struct {
+	mu          sync.Mutex
+	connections map[string]*ManagedConnection
+
+	// lastLogin is kept by device as connections are deleted when they are closed
+	lastLogin map[string]time.Time
+}
+
+// ManagedConnection is a connection owned by the ConnectionManager.
+// It is locked between Acquire and Release, so only one scrape at a time uses the shell of a device
+type ManagedConnection struct {
+	lock         chan struct{}
+	conn         Transport
+	netconf      io.Closer
+	deviceConfig *config.DeviceConfig
+	cfg          *config.Config
+	lastUsed     time.Time
+	removed      bool
+	OSType       string
+
+	// NoJSON holds the commands the device answered without JSON output, kept for the lifetime of the connection
+	NoJSON map[string]bool
+}
+
+// NewConnectionManager creates a new connection manager. Connections not used for the configured idle timeout are closed
+func NewConnectionManager() *ConnectionManager {
+	m := &ConnectionManager{
+		connections: make(map[string]*ManagedConnection),
+		lastLogin:   make(map[string]time.Time),
+	}
+	go m.closeIdleLoop()
+
+	return m
+}
+
+// Acquire locks the connection to a device. A new connection is established if there is none yet
+// or the existing one is broken, but not earlier than the configured minimum login interval after the last attempt.
+// The connection has to be released by calling Release afterwards.
+func (m *ConnectionManager) Acquire(ctx context.Context, device *Device, cfg *config.Config) (*ManagedConnection, error) {
+	var mc *ManagedConnection
+	for {
+		mc = m.managedConnection(device)
+		select {
+		case mc.lock <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		// the connection was removed from the manager while waiting for the lock
+		if !mc.removed {
+			break
+		}
+		mc.Release()
+	}
+
+	if mc.conn != nil && !mc.conn.isAlive() {
+		mc.close()
+	}
+
+	if mc.conn == nil {
+		wait := cfg.MinLoginInterval - time.Since(m.login(device))
+		if wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				mc.Release()
+				return nil, ctx.Err()
+			}
+		}
+		m.setLogin(device)
+
+		conn, err := NewConnection(ctx, device, cfg)
+		if err != nil {
+			mc.Release()
+			return nil, err
+		}
+		mc.conn = conn
+		mc.deviceConfig = device.DeviceConfig
+		mc.cfg = cfg
+		mc.OSType = ""
+		mc.NoJSON = make(map[string]bool)
+	}
+
+	return mc, nil
+}
+
+// CloseAll closes all connections
+func (m *ConnectionManager) CloseAll() {
+	m.mu.Lock()
+	connections := m.connections
+	m.connections = make(map[string]*ManagedConnection)
+	m.mu.Unlock()
+
+	for _, mc := range connections {
+		mc.lock <- struct{}{}
+		mc.close()
+		mc.removed = true
+		mc.Release()
+	}
+}
+
+// Retain closes the connections to all devices not in devices and those whose config changed.
+// Connections are closed in the background as soon as running scrapes released them, so Retain does not block
+func (m *ConnectionManager) Retain(devices []*Device, cfg *config.Config) {
+	retain := make(map[string]*Device)
+	for _, d := range devices {
+		retain[connectionKey(d)] = d
+	}
+
+	m.mu.Lock()
+	for key, mc := range m.connections {
+		go m.retain(key, mc, retain[key], cfg)
+	}
+	for key, t := range m.lastLogin {
+		if retain[key] == nil && time.Since(t) > cfg.MinLoginInterval {
+			delete(m.lastLogin, key)
+		}
+	}
+	m.mu.Unlock()
+}
+
+// retain closes the connection if the device was removed (d is nil) or its config changed.
+// Connections of removed devices are deleted from the manager after they are closed
+func (m *ConnectionManager) retain(key string, mc *ManagedConnection, d *Device, cfg *config.Config) {
+	mc.lock <- struct{}{}
+	defer mc.Release()
+
+	if mc.conn != nil && (d == nil || !mc.configEqual(d.DeviceConfig, cfg)) {
+		mc.close()
+	}
+	if d == nil {
+		m.remove(key, mc)
+	}
+}
+
+// CloseIdle closes the connections which have not been used for the idle timeout of their config and
+// deletes them from the manager. Connections in use are skipped
+func (m *ConnectionManager) CloseIdle() {
+	m.mu.Lock()
+	connections := make(map[string]*ManagedConnection)
+	for key, mc := range m.connections {
+		connections[key] = mc
+	}
+	m.mu.Unlock()
+
+	for key, mc := range connections {
+		select {
+		case mc.lock <- struct{}{}:
+		default:
+			continue
+		}
+
+		if mc.conn != nil && mc.cfg.IdleTimeout > 0 && time.Since(mc.lastUsed) > mc.cfg.IdleTimeout {
+			mc.close()
+			m.remove(key, mc)
+		}
+		mc.Release()
+	}
+}
+
+func (m *ConnectionManager) closeIdleLoop() {
+	for range time.Tick(idleCheckInterval) {
+		m.CloseIdle()
+	}
+}
+
+// remove deletes a connection from the manager. It has to be locked by the caller
+func (m *ConnectionManager) remove(key string, mc *ManagedConnection) {
+	m.mu.Lock()
+	if m.connections[key] == mc {
+		delete(m.connections, key)
+	}
+	m.mu.Unlock()
+
+	mc.removed = true
+}
+
+func (m *ConnectionManager) managedConnection(device *Device) *ManagedConnection {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := connectionKey(device)
+	mc, found := m.connections[key]
+	if !found {
+		mc = &ManagedConnection{
+			lock: make(chan struct{}, 1),
+		}
+		m.connections[key] = mc
+	}
+
+	return mc
+}
+
+// login returns the time of the last login attempt to the device
+func (m *ConnectionManager) login(device *Device) time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.lastLogin[connectionKey(device)]
+}
+
+func (m *ConnectionManager) setLogin(device *Device) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lastLogin[connectionKey(device)] = time.Now()
+}
+
+func connectionKey(device *Device) string {
+	return device.Host + ":" + device.Port
+}
+
+func (mc *ManagedConnection) configEqual(deviceConfig *config.DeviceConfig, cfg *config.Config) bool {
+	if !reflect.DeepEqual(mc.deviceConfig, deviceConfig) {
+		return false
+	}
+
+	previous, current := *mc.cfg, *cfg
+	previous.Devices, current.Devices = nil, nil
+
+	return reflect.DeepEqual(previous, current)
+}
+
+// Connection returns the connection to the device
+func (mc *ManagedConnection) Connection() Transport {
+	return mc.conn
+}
+
+// Netconf returns the NETCONF session to the device
+func (mc *ManagedConnection) Netconf() io.Closer {
+	return mc.netconf
+}
+
+// SetNetconf sets the NETCONF session to the device, which is closed together with the connection
+func (mc *ManagedConnection) SetNetconf(session io.Closer) {
+	mc.netconf = session
+}
+
+func (mc *ManagedConnection) close() {
+	if mc.netconf != nil {
+		mc.netconf.Close()
+		mc.netconf = nil
+	}
+
+	if mc.conn != nil {
+		mc.conn.Close()
+		mc.conn = nil
+	}
+}
+
+// Release unlocks the connection so it can be used by the next scrape
+func (mc *ManagedConnection) Release() {
+	mc.lastUsed = time.Now()
+	<-mc.lock
+}
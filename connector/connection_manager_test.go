@@ -0,0 +1,135 @@
+package connector
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lwlcom/cisco_exporter/config"
+)
+
+type fakeTransport struct {
+	closed int32
+}
+
+func (t *fakeTransport) Host() string { return "fake" }
+
+func (t *fakeTransport) RunCommand(ctx context.Context, cmd string) (string, error) { return "", nil }
+
+func (t *fakeTransport) Close() { atomic.StoreInt32(&t.closed, 1) }
+
+func (t *fakeTransport) isAlive() bool { return atomic.LoadInt32(&t.closed) == 0 }
+
+func (t *fakeTransport) isClosed() bool { return atomic.LoadInt32(&t.closed) == 1 }
+
+func newTestConnection(m *ConnectionManager, device *Device, cfg *config.Config) (*ManagedConnection, *fakeTransport) {
+	mc := m.managedConnection(device)
+	t := &fakeTransport{}
+	mc.conn = t
+	mc.deviceConfig = device.DeviceConfig
+	mc.cfg = cfg
+
+	return mc, t
+}
+
+func TestRetainDoesNotWaitForRunningScrape(t *testing.T) {
+	m := &ConnectionManager{connections: make(map[string]*ManagedConnection), lastLogin: make(map[string]time.Time)}
+	cfg := config.New()
+	device := &Device{Host: "router", Port: "22", DeviceConfig: &config.DeviceConfig{Host: "router"}}
+	mc, conn := newTestConnection(m, device, cfg)
+	mc.lock <- struct{}{}
+
+	done := make(chan struct{})
+	go func() {
+		m.Retain(nil, cfg)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Retain blocked by running scrape")
+	}
+
+	if conn.isClosed() {
+		t.Fatal("connection closed while in use")
+	}
+	m.mu.Lock()
+	_, found := m.connections[connectionKey(device)]
+	m.mu.Unlock()
+	if !found {
+		t.Fatal("connection deleted before it was closed")
+	}
+
+	mc.Release()
+	waitFor(t, func() bool {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		_, found := m.connections[connectionKey(device)]
+		return conn.isClosed() && !found
+	})
+
+	next := m.managedConnection(device)
+	if next == mc {
+		t.Fatal("removed connection is reused")
+	}
+}
+
+func TestCloseIdle(t *testing.T) {
+	m := &ConnectionManager{connections: make(map[string]*ManagedConnection), lastLogin: make(map[string]time.Time)}
+	cfg := config.New()
+	cfg.IdleTimeout = time.Minute
+	idle := &Device{Host: "idle", Port: "22", DeviceConfig: &config.DeviceConfig{Host: "idle"}}
+	active := &Device{Host: "active", Port: "22", DeviceConfig: &config.DeviceConfig{Host: "active"}}
+
+	idleMC, idleConn := newTestConnection(m, idle, cfg)
+	idleMC.lastUsed = time.Now().Add(-2 * time.Minute)
+	activeMC, activeConn := newTestConnection(m, active, cfg)
+	activeMC.lastUsed = time.Now()
+
+	m.CloseIdle()
+
+	if !idleConn.isClosed() || !idleMC.removed {
+		t.Fatal("idle connection was not closed")
+	}
+	if activeConn.isClosed() || activeMC.removed {
+		t.Fatal("active connection was closed")
+	}
+}
+
+func TestMinLoginIntervalAfterIdleClose(t *testing.T) {
+	m := &ConnectionManager{connections: make(map[string]*ManagedConnection), lastLogin: make(map[string]time.Time)}
+	cfg := config.New()
+	cfg.IdleTimeout = time.Minute
+	cfg.MinLoginInterval = time.Hour
+	device := &Device{Host: "router", Port: "22", DeviceConfig: &config.DeviceConfig{Host: "router"}}
+
+	mc, conn := newTestConnection(m, device, cfg)
+	m.setLogin(device)
+	mc.lastUsed = time.Now().Add(-2 * time.Minute)
+
+	m.CloseIdle()
+	m.Retain([]*Device{device}, cfg)
+	if !conn.isClosed() {
+		t.Fatal("idle connection was not closed")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := m.Acquire(ctx, device, cfg)
+	if err != context.DeadlineExceeded {
+		t.Fatalf("expected to wait for the minimum login interval, got %v", err)
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,51 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var passwordPromptRegexp = regexp.MustCompile(`(?i)password:\s*$`)
+
+// EnableError is returned if a device could not be switched to privileged EXEC mode
+type EnableError struct {
+	Host string
+	Err  error
+}
+
+func (e *EnableError) Error() string {
+	return fmt.Sprintf("enable failed for %s: %v", e.Host, e.Err)
+}
+
+// enable switches from user EXEC mode to privileged EXEC mode
+func (s *shell) enable(ctx context.Context) error {
+	if s.enablePassword == nil {
+		return &EnableError{Host: s.host, Err: errors.New("device is in user EXEC mode and no enable password is configured")}
+	}
+
+	done := func(line string) bool {
+		return passwordPromptRegexp.MatchString(line) || s.isPrompt(line)
+	}
+
+	out, err := s.exec(ctx, "enable", "enable", done)
+	if err != nil {
+		return err
+	}
+
+	if passwordPromptRegexp.MatchString(lastLine(out)) {
+		out, err = s.exec(ctx, *s.enablePassword, "", done)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !strings.HasSuffix(strings.TrimSpace(lastLine(out)), "#") {
+		return &EnableError{Host: s.host, Err: errors.New("enable password was not accepted")}
+	}
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package connector
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/lwlcom/cisco_exporter/config"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+var knownHostsMu sync.Mutex
+
+// HostKeyError is returned if the host key presented by a device could not be verified
+type HostKeyError struct {
+	Host string
+	Err  error
+}
+
+func (e *HostKeyError) Error() string {
+	return fmt.Sprintf("host key verification failed for %s: %v", e.Host, e.Err)
+}
+
+// hostKeyCallback creates the callback to verify host keys of a device. Pinned fingerprints
+// of the device take precedence over the known_hosts file. If neither is configured, host keys are not verified.
+func hostKeyCallback(device *Device, cfg *config.Config) ssh.HostKeyCallback {
+	fingerprints := device.DeviceConfig.HostKeyFingerprints
+	if len(fingerprints) > 0 {
+		return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			if !matchesFingerprint(key, fingerprints) {
+				return &HostKeyError{
+					Host: hostname,
+					Err:  fmt.Errorf("fingerprint %s does not match any pinned fingerprint", ssh.FingerprintSHA256(key)),
+				}
+			}
+			return nil
+		}
+	}
+
+	if len(cfg.KnownHostsFile) == 0 {
+		return ssh.InsecureIgnoreHostKey()
+	}
+
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		err := verifyKnownHost(cfg.KnownHostsFile, cfg.TrustOnFirstUse, hostname, remote, key)
+		if err != nil {
+			return &HostKeyError{Host: hostname, Err: err}
+		}
+		return nil
+	}
+}
+
+func matchesFingerprint(key ssh.PublicKey, fingerprints []string) bool {
+	sha256 := ssh.FingerprintSHA256(key)
+	md5 := ssh.FingerprintLegacyMD5(key)
+
+	for _, f := range fingerprints {
+		f = strings.TrimSpace(f)
+		if f == sha256 {
+			return true
+		}
+		if strings.EqualFold(strings.TrimPrefix(f, "MD5:"), md5) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func verifyKnownHost(file string, tofu bool, hostname string, remote net.Addr, key ssh.PublicKey) error {
+	knownHostsMu.Lock()
+	defer knownHostsMu.Unlock()
+
+	if tofu {
+		f, err := os.OpenFile(file, os.O_CREATE|os.O_RDONLY, 0600)
+		if err != nil {
+			return errors.Wrap(err, "could not create known_hosts file")
+		}
+		f.Close()
+	}
+
+	cb, err := knownhosts.New(file)
+	if err != nil {
+		return errors.Wrap(err, "could not load known_hosts file")
+	}
+
+	err = cb(hostname, remote, key)
+	if err == nil {
+		return nil
+	}
+
+	var keyErr *knownhosts.KeyError
+	if !tofu || !errors.As(err, &keyErr) || len(keyErr.Want) > 0 {
+		return err
+	}
+
+	return addKnownHost(file, hostname, key)
+}
+
+func addKnownHost(file string, hostname string, key ssh.PublicKey) error {
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return errors.Wrap(err, "could not open known_hosts file")
+	}
+	defer f.Close()
+
+	_, err = f.WriteString(knownhosts.Line([]string{knownhosts.Normalize(hostname)}, key) + "\n")
+	if err != nil {
+		return errors.Wrap(err, "could not write known_hosts file")
+	}
+
+	log.Infof("Added host key %s of %s to %s", ssh.FingerprintSHA256(key), hostname, file)
+	return nil
+}
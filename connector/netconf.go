@@ -0,0 +1,124 @@
+package connector
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/lwlcom/cisco_exporter/config"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+)
+
+// NewNetconfConnection connects to the NETCONF SSH subsystem of device
+func NewNetconfConnection(ctx context.Context, device *Device, cfg *config.Config) (*NetconfConnection, error) {
+	port := cfg.NetconfPort
+	if device.DeviceConfig.NetconfPort != nil {
+		port = *device.DeviceConfig.NetconfPort
+	}
+
+	var hostKeyErr error
+	c := &NetconfConnection{
+		host:          device.Host + ":" + strconv.Itoa(port),
+		timeout:       timeoutForDevice(device, cfg),
+		maxOutputSize: shellConfigForDevice(device, cfg).maxOutputSize,
+	}
+	if c.maxOutputSize <= 0 {
+		c.maxOutputSize = defaultMaxOutputSize
+	}
+	sshConfig := sshClientConfig(device, cfg, &hostKeyErr)
+
+	dial, jc, err := dialForDevice(ctx, device, cfg)
+	if err != nil {
+		return nil, err
+	}
+	c.jumpClient = jc
+
+	err = c.connect(ctx, dial, sshConfig)
+	if hostKeyErr != nil {
+		c.Close()
+		return nil, hostKeyErr
+	}
+	if err != nil {
+		c.Close()
+		return nil, errors.Wrap(err, "could not connect to netconf subsystem")
+	}
+
+	return c, nil
+}
+
+// NetconfConnection is the SSH connection to the NETCONF subsystem of a device
+type NetconfConnection struct {
+	host          string
+	timeout       time.Duration
+	maxOutputSize int
+	client        *ssh.Client
+	session       *ssh.Session
+	in            io.WriteCloser
+	out           io.Reader
+	jumpClient    *jumpClient
+}
+
+func (c *NetconfConnection) connect(ctx context.Context, dial dialFunc, sshConfig *ssh.ClientConfig) error {
+	dialCtx, cancel := context.WithTimeout(ctx, sshConfig.Timeout)
+	conn, err := dial(dialCtx, "tcp", c.host)
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	c.client, err = newClient(ctx, conn, c.host, sshConfig)
+	if err != nil {
+		return err
+	}
+
+	c.session, err = c.client.NewSession()
+	if err != nil {
+		return err
+	}
+	c.in, _ = c.session.StdinPipe()
+	c.out, _ = c.session.StdoutPipe()
+
+	return c.session.RequestSubsystem("netconf")
+}
+
+// Host returns the address of the NETCONF server of the device
+func (c *NetconfConnection) Host() string {
+	return c.host
+}
+
+// Timeout returns the time to wait for a reply of the device
+func (c *NetconfConnection) Timeout() time.Duration {
+	return c.timeout
+}
+
+// MaxOutputSize returns the maximum size of a reply of the device
+func (c *NetconfConnection) MaxOutputSize() int {
+	return c.maxOutputSize
+}
+
+func (c *NetconfConnection) Read(b []byte) (int, error) {
+	return c.out.Read(b)
+}
+
+func (c *NetconfConnection) Write(b []byte) (int, error) {
+	return c.in.Write(b)
+}
+
+// Close closes the connection
+func (c *NetconfConnection) Close() error {
+	if c.jumpClient != nil {
+		jumpClients.release(c.jumpClient)
+		c.jumpClient = nil
+	}
+
+	if c.session != nil {
+		c.session.Close()
+	}
+	if c.client != nil {
+		return c.client.Close()
+	}
+
+	return nil
+}
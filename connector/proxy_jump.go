@@ -0,0 +1,190 @@
+package connector
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/lwlcom/cisco_exporter/config"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+)
+
+var jumpClients = &jumpClientPool{
+	clients: make(map[string]*jumpClient),
+}
+
+// jumpClientPool shares the connections to jump hosts between all devices reached over the same hops.
+// A connection is closed as soon as no connection to a device uses it anymore
+type jumpClientPool struct {
+	mu      sync.Mutex
+	clients map[string]*jumpClient
+}
+
+// jumpClient is the connection to the last of a chain of hops
+type jumpClient struct {
+	key    string
+	client *ssh.Client
+	parent *jumpClient
+	refs   int
+	ready  chan struct{}
+	err    error
+}
+
+// acquire gets the connection to the last hop, connecting to all hops not connected yet in order.
+// The connection has to be released by calling release afterwards
+func (p *jumpClientPool) acquire(ctx context.Context, hops []*Device, cfg *config.Config) (*jumpClient, error) {
+	key := jumpKey(hops)
+
+	p.mu.Lock()
+	jc, found := p.clients[key]
+	if !found {
+		jc = &jumpClient{
+			key:   key,
+			ready: make(chan struct{}),
+		}
+		p.clients[key] = jc
+	}
+	jc.refs++
+	p.mu.Unlock()
+
+	if !found {
+		jc.err = p.connect(ctx, jc, hops, cfg)
+		if jc.err != nil {
+			p.remove(jc)
+		}
+		close(jc.ready)
+	}
+
+	select {
+	case <-jc.ready:
+	case <-ctx.Done():
+		p.release(jc)
+		return nil, ctx.Err()
+	}
+
+	if jc.err != nil {
+		p.release(jc)
+		return nil, jc.err
+	}
+
+	return jc, nil
+}
+
+func (p *jumpClientPool) connect(ctx context.Context, jc *jumpClient, hops []*Device, cfg *config.Config) error {
+	hop := hops[len(hops)-1]
+
+	var dial dialFunc
+	if len(hops) > 1 {
+		parent, err := p.acquire(ctx, hops[:len(hops)-1], cfg)
+		if err != nil {
+			return err
+		}
+		jc.parent = parent
+		dial = parent.dial
+	} else {
+		dialer, err := dialerForDevice(hop, cfg)
+		if err != nil {
+			return err
+		}
+		dial = dialer.DialContext
+	}
+
+	addr := hop.Host + ":" + hop.Port
+
+	var hostKeyErr error
+	sshConfig := sshClientConfig(hop, cfg, &hostKeyErr)
+
+	dialCtx, cancel := context.WithTimeout(ctx, sshConfig.Timeout)
+	conn, err := dial(dialCtx, "tcp", addr)
+	cancel()
+	if err != nil {
+		return errors.Wrapf(err, "could not connect to %s", addr)
+	}
+
+	jc.client, err = newClient(ctx, conn, addr, sshConfig)
+	if hostKeyErr != nil {
+		return hostKeyErr
+	}
+	if err != nil {
+		return errors.Wrapf(err, "could not connect to %s", addr)
+	}
+
+	go func() {
+		jc.client.Wait()
+		p.remove(jc)
+	}()
+
+	return nil
+}
+
+// remove removes a broken connection from the pool, so the next device connects again
+func (p *jumpClientPool) remove(jc *jumpClient) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.clients[jc.key] == jc {
+		delete(p.clients, jc.key)
+	}
+}
+
+// release closes the connection if it is not used anymore
+func (p *jumpClientPool) release(jc *jumpClient) {
+	p.mu.Lock()
+	jc.refs--
+	unused := jc.refs == 0
+	if unused && p.clients[jc.key] == jc {
+		delete(p.clients, jc.key)
+	}
+	p.mu.Unlock()
+
+	if !unused {
+		return
+	}
+
+	if jc.client != nil {
+		jc.client.Close()
+	}
+	if jc.parent != nil {
+		p.release(jc.parent)
+	}
+}
+
+// dial opens a connection to addr tunneled through the jump host
+func (jc *jumpClient) dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	type result struct {
+		conn net.Conn
+		err  error
+	}
+
+	ch := make(chan result, 1)
+	go func() {
+		conn, err := jc.client.Dial(network, addr)
+		ch <- result{conn: conn, err: err}
+	}()
+
+	select {
+	case res := <-ch:
+		return res.conn, res.err
+	case <-ctx.Done():
+		go func() {
+			if res := <-ch; res.conn != nil {
+				res.conn.Close()
+			}
+		}()
+		return nil, ctx.Err()
+	}
+}
+
+// jumpKey identifies a chain of hops by user, host and port of every hop
+func jumpKey(hops []*Device) string {
+	keys := make([]string, len(hops))
+	for i, h := range hops {
+		c := &ssh.ClientConfig{}
+		h.Auth.apply(c)
+		keys[i] = c.User + "@" + h.Host + ":" + h.Port
+	}
+
+	return strings.Join(keys, ",")
+}
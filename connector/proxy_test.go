@@ -0,0 +1,233 @@
+package connector
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// listen starts a TCP listener on localhost handling every connection with handle
+func listen(t *testing.T, handle func(conn net.Conn)) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				handle(conn)
+			}()
+		}
+	}()
+
+	return l.Addr().String()
+}
+
+func echoServer(t *testing.T) string {
+	return listen(t, func(conn net.Conn) {
+		io.Copy(conn, conn)
+	})
+}
+
+// pipe copies data between the client connection of a proxy and the target
+func pipe(client net.Conn, r io.Reader, addr string) {
+	target, err := net.Dial("tcp", addr)
+	if err != nil {
+		return
+	}
+	defer target.Close()
+
+	go io.Copy(target, r)
+	io.Copy(client, target)
+}
+
+// socks5Server is a minimal SOCKS5 proxy requiring username/password auth.
+// The destination addresses requested are sent to requests
+func socks5Server(t *testing.T, user, password string, requests chan<- string) string {
+	return listen(t, func(conn net.Conn) {
+		buf := make([]byte, 2)
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			return
+		}
+		methods := make([]byte, buf[1])
+		if _, err := io.ReadFull(conn, methods); err != nil {
+			return
+		}
+		if !strings.Contains(string(methods), string([]byte{socks5AuthPassword})) {
+			conn.Write([]byte{socks5Version, socks5AuthNoAcceptable})
+			return
+		}
+		conn.Write([]byte{socks5Version, socks5AuthPassword})
+
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			return
+		}
+		u := make([]byte, buf[1])
+		io.ReadFull(conn, u)
+		io.ReadFull(conn, buf[:1])
+		p := make([]byte, buf[0])
+		io.ReadFull(conn, p)
+		if string(u) != user || string(p) != password {
+			conn.Write([]byte{0x01, 0x01})
+			return
+		}
+		conn.Write([]byte{0x01, 0x00})
+
+		req := make([]byte, 4)
+		if _, err := io.ReadFull(conn, req); err != nil {
+			return
+		}
+		var host string
+		switch req[3] {
+		case socks5AddrIPv4:
+			ip := make([]byte, net.IPv4len)
+			io.ReadFull(conn, ip)
+			host = "ip:" + net.IP(ip).String()
+		case socks5AddrDomain:
+			io.ReadFull(conn, buf[:1])
+			name := make([]byte, buf[0])
+			io.ReadFull(conn, name)
+			host = "domain:" + string(name)
+		default:
+			conn.Write([]byte{socks5Version, 0x08, 0x00, socks5AddrIPv4, 0, 0, 0, 0, 0, 0})
+			return
+		}
+		io.ReadFull(conn, buf)
+		port := int(buf[0])<<8 | int(buf[1])
+		requests <- host
+
+		addr := net.JoinHostPort(strings.SplitN(host, ":", 2)[1], strconv.Itoa(port))
+		if strings.HasPrefix(host, "domain:") && strings.SplitN(host, ":", 2)[1] == "unreachable.invalid" {
+			conn.Write([]byte{socks5Version, 0x04, 0x00, socks5AddrIPv4, 0, 0, 0, 0, 0, 0})
+			return
+		}
+		conn.Write([]byte{socks5Version, 0x00, 0x00, socks5AddrDomain, 4, 'p', 'r', 'o', 'x', 0, 0})
+		pipe(conn, conn, addr)
+	})
+}
+
+// httpConnectServer is a minimal HTTP CONNECT proxy requiring basic auth
+func httpConnectServer(t *testing.T, user, password string) string {
+	return listen(t, func(conn net.Conn) {
+		r := bufio.NewReader(conn)
+		req, err := http.ReadRequest(r)
+		if err != nil {
+			return
+		}
+
+		auth := "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+password))
+		if req.Method != http.MethodConnect || req.Header.Get("Proxy-Authorization") != auth {
+			conn.Write([]byte("HTTP/1.1 407 Proxy Authentication Required\r\n\r\n"))
+			return
+		}
+
+		conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+		pipe(conn, r, req.Host)
+	})
+}
+
+func assertEcho(t *testing.T, d Dialer, addr string) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if _, err = conn.Write([]byte("show version\n")); err != nil {
+		t.Fatal(err)
+	}
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line != "show version\n" {
+		t.Fatalf("unexpected response %q", line)
+	}
+}
+
+func TestSOCKS5Proxy(t *testing.T) {
+	_, port, _ := net.SplitHostPort(echoServer(t))
+	requests := make(chan string, 1)
+	proxy := socks5Server(t, "exporter", "secret", requests)
+
+	tests := []struct {
+		scheme   string
+		expected string
+	}{
+		{scheme: "socks5", expected: "ip:127.0.0.1"},
+		{scheme: "socks5h", expected: "domain:localhost"},
+	}
+	for _, test := range tests {
+		t.Run(test.scheme, func(t *testing.T) {
+			d, err := NewProxyDialer(test.scheme+"://exporter:secret@"+proxy, &net.Dialer{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assertEcho(t, d, net.JoinHostPort("localhost", port))
+			if r := <-requests; r != test.expected {
+				t.Fatalf("expected request for %s, got %s", test.expected, r)
+			}
+		})
+	}
+}
+
+func TestSOCKS5ProxyErrors(t *testing.T) {
+	requests := make(chan string, 1)
+	proxy := socks5Server(t, "exporter", "secret", requests)
+	ctx := context.Background()
+
+	d, _ := NewProxyDialer("socks5h://exporter:wrong@"+proxy, &net.Dialer{})
+	_, err := d.DialContext(ctx, "tcp", "localhost:22")
+	if err == nil || !strings.Contains(err.Error(), "authentication failed") {
+		t.Fatalf("expected authentication error, got %v", err)
+	}
+
+	d, _ = NewProxyDialer("socks5h://exporter:secret@"+proxy, &net.Dialer{})
+	_, err = d.DialContext(ctx, "tcp", "unreachable.invalid:22")
+	if err == nil || !strings.Contains(err.Error(), "host unreachable") {
+		t.Fatalf("expected host unreachable error, got %v", err)
+	}
+}
+
+func TestHTTPConnectProxy(t *testing.T) {
+	target := echoServer(t)
+	proxy := httpConnectServer(t, "exporter", "secret")
+
+	d, err := NewProxyDialer("http://exporter:secret@"+proxy, &net.Dialer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEcho(t, d, target)
+
+	d, _ = NewProxyDialer("http://exporter:wrong@"+proxy, &net.Dialer{})
+	_, err = d.DialContext(context.Background(), "tcp", target)
+	if err == nil || !strings.Contains(err.Error(), "407") {
+		t.Fatalf("expected proxy authentication error, got %v", err)
+	}
+}
+
+func TestNewProxyDialerUnsupportedScheme(t *testing.T) {
+	if _, err := NewProxyDialer("socks4://proxy:1080", &net.Dialer{}); err == nil {
+		t.Fatal("expected error for unsupported scheme")
+	}
+}
@@ -0,0 +1,246 @@
+package connector
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/lwlcom/cisco_exporter/config"
+	"github.com/pkg/errors"
+)
+
+const restconfMediaType = "application/yang-data+json"
+
+// NewRestconfConnection creates a connection to the RESTCONF API of device.
+// The YANG modules supported by the device are read from its YANG library, which also verifies the credentials
+func NewRestconfConnection(ctx context.Context, device *Device, cfg *config.Config) (*RestconfConnection, error) {
+	tlsConfig, err := tlsConfigForDevice(device, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	username, password := device.Auth.credentials()
+	timeout := timeoutForDevice(device, cfg)
+	c := &RestconfConnection{
+		host:          device.Host + ":" + device.Port,
+		baseURL:       "https://" + net.JoinHostPort(device.Host, device.Port) + "/restconf",
+		username:      username,
+		password:      password,
+		maxOutputSize: shellConfigForDevice(device, cfg).maxOutputSize,
+		timeout:       timeout,
+	}
+	if c.maxOutputSize <= 0 {
+		c.maxOutputSize = defaultMaxOutputSize
+	}
+
+	var dial dialFunc
+	dial, c.jumpClient, err = dialForDevice(ctx, device, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	c.client = &http.Client{
+		Transport: &http.Transport{
+			DialContext:         dial,
+			TLSClientConfig:     tlsConfig,
+			TLSHandshakeTimeout: timeout,
+			MaxIdleConnsPerHost: 1,
+		},
+	}
+
+	err = c.loadModules(ctx)
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// RestconfConnection is the connection to the RESTCONF API (RFC 8040) of a device
+type RestconfConnection struct {
+	host          string
+	baseURL       string
+	username      string
+	password      string
+	maxOutputSize int
+	timeout       time.Duration
+	client        *http.Client
+	modules       map[string]bool
+	jumpClient    *jumpClient
+}
+
+type restconfErrors struct {
+	Errors struct {
+		Error []struct {
+			Type    string `json:"error-type"`
+			Tag     string `json:"error-tag"`
+			Message string `json:"error-message"`
+		} `json:"error"`
+	} `json:"ietf-restconf:errors"`
+}
+
+type yangLibrary struct {
+	ModulesState struct {
+		Module []struct {
+			Name string `json:"name"`
+		} `json:"module"`
+	} `json:"ietf-yang-library:modules-state"`
+}
+
+func (c *RestconfConnection) loadModules(ctx context.Context) error {
+	b, err := c.Get(ctx, "ietf-yang-library:modules-state")
+	if err != nil {
+		return errors.Wrap(err, "could not read yang library")
+	}
+
+	var lib yangLibrary
+	err = json.Unmarshal(b, &lib)
+	if err != nil {
+		return errors.Wrap(err, "could not parse yang library")
+	}
+
+	c.modules = make(map[string]bool)
+	for _, m := range lib.ModulesState.Module {
+		c.modules[m.Name] = true
+	}
+
+	return nil
+}
+
+// Host returns the address of the device
+func (c *RestconfConnection) Host() string {
+	return c.host
+}
+
+// HasModule checks if the device supports a YANG module
+func (c *RestconfConnection) HasModule(module string) bool {
+	return c.modules[module]
+}
+
+// Get gets the JSON encoded data resource at path (e.g. Cisco-IOS-XE-interfaces-oper:interfaces)
+func (c *RestconfConnection) Get(ctx context.Context, path string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/data/"+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", restconfMediaType)
+	req.SetBasicAuth(c.username, c.password)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(&limitedReader{r: resp.Body, n: c.maxOutputSize})
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, restconfError(resp.Status, b)
+	}
+
+	return b, nil
+}
+
+func restconfError(status string, body []byte) error {
+	var e restconfErrors
+	if json.Unmarshal(body, &e) != nil || len(e.Errors.Error) == 0 {
+		return fmt.Errorf("restconf request failed: %s", status)
+	}
+
+	msgs := make([]string, len(e.Errors.Error))
+	for i, err := range e.Errors.Error {
+		msgs[i] = err.Tag
+		if len(err.Message) > 0 {
+			msgs[i] = err.Message
+		}
+	}
+
+	return fmt.Errorf("restconf request failed: %s (%s)", status, strings.Join(msgs, ", "))
+}
+
+// RunCommand is not supported, as RESTCONF provides no access to the CLI
+func (c *RestconfConnection) RunCommand(ctx context.Context, cmd string) (string, error) {
+	return "", errors.New("CLI commands are not supported by the restconf transport")
+}
+
+// Close closes connection
+func (c *RestconfConnection) Close() {
+	if c.jumpClient != nil {
+		jumpClients.release(c.jumpClient)
+		c.jumpClient = nil
+	}
+
+	c.client.CloseIdleConnections()
+}
+
+// isAlive always returns true, as every request uses a new or pooled HTTP connection
+func (c *RestconfConnection) isAlive() bool {
+	return true
+}
+
+func tlsConfigForDevice(device *Device, cfg *config.Config) (*tls.Config, error) {
+	t := cfg.TLS
+	if device.DeviceConfig.TLS != nil {
+		t = device.DeviceConfig.TLS
+	}
+
+	tlsConfig := &tls.Config{}
+	if t == nil {
+		return tlsConfig, nil
+	}
+
+	tlsConfig.ServerName = t.ServerName
+	tlsConfig.InsecureSkipVerify = t.InsecureSkipVerify
+
+	if len(t.CAFile) > 0 {
+		b, err := ioutil.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not read tls ca file")
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(b) {
+			return nil, errors.New("could not parse tls ca file: no certificates found")
+		}
+	}
+
+	if len(t.CertFile) > 0 {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not load tls client certificate")
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// limitedReader returns an error if more than n bytes are read
+type limitedReader struct {
+	r io.Reader
+	n int
+}
+
+func (l *limitedReader) Read(b []byte) (int, error) {
+	n, err := l.r.Read(b)
+	l.n -= n
+	if l.n < 0 {
+		return n, errors.New("restconf response exceeds maximum output size")
+	}
+
+	return n, err
+}
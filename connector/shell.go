@@ -0,0 +1,199 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const defaultMaxOutputSize = 10 * 1024 * 1024
+
+// tailSize is the size of the end of the output checked for prompts and pagers
+const tailSize = 1024
+
+var (
+	// the CLI of Firepower Threat Defense has a prompt without hostname (>)
+	loginPromptRegexp = regexp.MustCompile(`^(\w[^\s#>]*[#>]|>)\s?$`)
+	pagerRegexp       = regexp.MustCompile(`(--More--|<--- More --->)\s*$`)
+	pagerCleanRegexp  = regexp.MustCompile(` ?(--More--|<--- More --->) ?(\x08+ *\x08+|\x08+)?`)
+	ansiRegexp        = regexp.MustCompile(`\x1b(\[[0-9;?]*[a-zA-Z]|[()][A-Z0-9]|[=>])`)
+
+	// xrPromptRegexp matches the prompt of IOS-XR devices, which starts with the node the session is connected to (RP/0/RSP0/CPU0:host#)
+	xrPromptRegexp = regexp.MustCompile(`^(RP|LC)/\d+/\w+/CPU\d+:(.+)$`)
+)
+
+// shell runs commands on the interactive CLI of a device. The prompt of the device is learned after login
+// and a command is considered complete as soon as its output ends with the prompt
+type shell struct {
+	host           string
+	in             io.Writer
+	out            io.Reader
+	batchSize      int
+	maxOutputSize  int
+	enablePassword *string
+	timeout        time.Duration
+	abort          func()
+	prompt         *regexp.Regexp
+	broken         bool
+}
+
+type result struct {
+	output string
+	err    error
+}
+
+func newShell(host string, in io.Writer, out io.Reader, cfg shellConfig, timeout time.Duration, abort func()) *shell {
+	if cfg.maxOutputSize <= 0 {
+		cfg.maxOutputSize = defaultMaxOutputSize
+	}
+
+	return &shell{
+		host:           host,
+		in:             in,
+		out:            out,
+		batchSize:      cfg.batchSize,
+		maxOutputSize:  cfg.maxOutputSize,
+		enablePassword: cfg.enablePassword,
+		timeout:        timeout,
+		abort:          abort,
+	}
+}
+
+// start waits for the prompt after login, switches to privileged EXEC mode if needed and disables paging
+func (s *shell) start(ctx context.Context) error {
+	out, err := s.exec(ctx, "", "", func(line string) bool {
+		return loginPromptRegexp.MatchString(line)
+	})
+	if err != nil {
+		return err
+	}
+
+	prompt := strings.TrimSpace(lastLine(out))
+	hostname := strings.TrimRight(prompt, "#>")
+	xr := xrPromptRegexp.FindStringSubmatch(hostname)
+	if xr != nil {
+		s.prompt = regexp.MustCompile(`^(RP|LC)/\d+/\w+/CPU\d+:` + regexp.QuoteMeta(xr[2]) + `(\([\w.\-]+\))?#\s?$`)
+	} else {
+		s.prompt = regexp.MustCompile(`^` + regexp.QuoteMeta(hostname) + `(\([\w.\-]+\))?[#>]\s?$`)
+	}
+
+	if strings.HasSuffix(prompt, ">") && len(hostname) > 0 {
+		err = s.enable(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	out, err = s.run(ctx, "terminal length 0")
+	if err != nil {
+		return errors.Wrap(err, "could not disable paging")
+	}
+	if strings.Contains(out, "Invalid input") {
+		// ASA and FTD only know terminal pager
+		_, err = s.run(ctx, "terminal pager 0")
+		if err != nil {
+			return errors.Wrap(err, "could not disable paging")
+		}
+	}
+	if xr != nil {
+		// IOS-XR prints a timestamp before the output of every command otherwise
+		_, err = s.run(ctx, "terminal exec prompt no-timestamp")
+		if err != nil {
+			return errors.Wrap(err, "could not disable timestamps")
+		}
+	}
+
+	return ctx.Err()
+}
+
+// run runs a command and returns its output
+func (s *shell) run(ctx context.Context, cmd string) (string, error) {
+	return s.exec(ctx, cmd, cmd, s.isPrompt)
+}
+
+func (s *shell) isPrompt(line string) bool {
+	return s.prompt.MatchString(line)
+}
+
+// exec sends input to the device and reads the output until it contains echo and done returns true for the last line
+func (s *shell) exec(ctx context.Context, input, echo string, done func(line string) bool) (string, error) {
+	io.WriteString(s.in, input+"\n")
+
+	return s.await(ctx, echo, done)
+}
+
+// await reads the output of the device until it contains echo and done returns true for the last line.
+// If the context is done or the timeout is reached before, the shell is aborted as it is in an unknown state afterwards
+func (s *shell) await(ctx context.Context, echo string, done func(line string) bool) (string, error) {
+	outputChan := make(chan result, 1)
+	go func() {
+		outputChan <- s.read(echo, done)
+	}()
+
+	select {
+	case res := <-outputChan:
+		if res.err != nil {
+			s.broken = true
+		}
+		return res.output, res.err
+	case <-ctx.Done():
+		s.broken = true
+		s.abort()
+		return "", ctx.Err()
+	case <-time.After(s.timeout):
+		s.broken = true
+		s.abort()
+		return "", errors.New("Timeout reached")
+	}
+}
+
+// read reads the output until it contains echo and done returns true for the last line.
+// Pagers are answered with a space, pager prompts and ANSI escape sequences are removed from the output
+func (s *shell) read(echo string, done func(line string) bool) result {
+	buf := make([]byte, s.batchSize)
+	output := make([]byte, 0, s.batchSize)
+	echoSeen := len(echo) == 0
+	for {
+		n, err := s.out.Read(buf)
+		if err != nil {
+			return result{err: err}
+		}
+
+		output = append(output, buf[:n]...)
+		if len(output) > s.maxOutputSize {
+			return result{err: fmt.Errorf("output exceeds maximum size of %d bytes", s.maxOutputSize)}
+		}
+
+		if !echoSeen {
+			echoSeen = strings.Contains(cleanOutput(string(output)), echo)
+		}
+
+		tail := output
+		if len(tail) > tailSize {
+			tail = tail[len(tail)-tailSize:]
+		}
+		cleaned := cleanOutput(string(tail))
+		if pagerRegexp.MatchString(cleaned) {
+			io.WriteString(s.in, " ")
+			continue
+		}
+
+		if echoSeen && done(lastLine(cleaned)) {
+			return result{output: pagerCleanRegexp.ReplaceAllString(cleanOutput(string(output)), "")}
+		}
+	}
+}
+
+func cleanOutput(output string) string {
+	output = ansiRegexp.ReplaceAllString(output, "")
+	return strings.Replace(output, "\r", "", -1)
+}
+
+func lastLine(output string) string {
+	return output[strings.LastIndex(output, "\n")+1:]
+}
@@ -0,0 +1,273 @@
+package connector
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"regexp"
+	"time"
+
+	"github.com/lwlcom/cisco_exporter/config"
+	"github.com/pkg/errors"
+)
+
+const (
+	telnetSE   = 240
+	telnetNOP  = 241
+	telnetSB   = 250
+	telnetWILL = 251
+	telnetWONT = 252
+	telnetDO   = 253
+	telnetDONT = 254
+	telnetIAC  = 255
+
+	telnetOptEcho = 1
+	telnetOptSGA  = 3
+)
+
+var usernamePromptRegexp = regexp.MustCompile(`(?i)(user ?name|login):\s*$`)
+
+// NewTelnetConnection connects to device using telnet
+func NewTelnetConnection(ctx context.Context, device *Device, cfg *config.Config) (*TelnetConnection, error) {
+	username, password := device.Auth.credentials()
+
+	c := &TelnetConnection{
+		host:        device.Host + ":" + device.Port,
+		username:    username,
+		password:    password,
+		shellConfig: shellConfigForDevice(device, cfg),
+		timeout:     timeoutForDevice(device, cfg),
+	}
+
+	var err error
+	c.dial, c.jumpClient, err = dialForDevice(ctx, device, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.Connect(ctx)
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// TelnetConnection encapsulates the telnet connection to the device
+type TelnetConnection struct {
+	host        string
+	username    string
+	password    string
+	conn        *telnetConn
+	shell       *shell
+	shellConfig shellConfig
+	timeout     time.Duration
+	dial        dialFunc
+	jumpClient  *jumpClient
+}
+
+// Connect connects to the device
+func (c *TelnetConnection) Connect(ctx context.Context) error {
+	dialCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	conn, err := c.dial(dialCtx, "tcp", c.host)
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	c.conn = &telnetConn{Conn: conn}
+	c.shell = newShell(c.host, c.conn, c.conn, c.shellConfig, c.timeout, c.Close)
+
+	err = c.login(ctx)
+	if err != nil {
+		return err
+	}
+
+	return c.shell.start(ctx)
+}
+
+// login answers the username and password prompts until the device shows its prompt
+func (c *TelnetConnection) login(ctx context.Context) error {
+	done := func(line string) bool {
+		return usernamePromptRegexp.MatchString(line) || passwordPromptRegexp.MatchString(line) || loginPromptRegexp.MatchString(line)
+	}
+
+	out, err := c.shell.await(ctx, "", done)
+	if err != nil {
+		return err
+	}
+
+	if usernamePromptRegexp.MatchString(lastLine(out)) {
+		out, err = c.shell.exec(ctx, c.username, "", done)
+		if err != nil {
+			return err
+		}
+	}
+
+	if passwordPromptRegexp.MatchString(lastLine(out)) {
+		out, err = c.shell.exec(ctx, c.password, "", done)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !loginPromptRegexp.MatchString(lastLine(out)) {
+		return errors.New("telnet login failed: username or password was not accepted")
+	}
+
+	return nil
+}
+
+// Host returns the address of the device
+func (c *TelnetConnection) Host() string {
+	return c.host
+}
+
+// RunCommand runs a command against the device. If the context is done or the timeout is reached
+// before the command completed, the connection is closed as the shell is in an unknown state afterwards
+func (c *TelnetConnection) RunCommand(ctx context.Context, cmd string) (string, error) {
+	return c.shell.run(ctx, cmd)
+}
+
+// Close closes connection
+func (c *TelnetConnection) Close() {
+	if c.jumpClient != nil {
+		jumpClients.release(c.jumpClient)
+		c.jumpClient = nil
+	}
+
+	if c.conn != nil {
+		c.conn.Close()
+	}
+}
+
+// isAlive checks if the connection can still be used for further commands
+func (c *TelnetConnection) isAlive() bool {
+	if c.shell == nil || c.shell.broken {
+		return false
+	}
+
+	c.conn.SetWriteDeadline(time.Now().Add(c.timeout))
+	defer c.conn.SetWriteDeadline(time.Time{})
+
+	_, err := c.conn.Conn.Write([]byte{telnetIAC, telnetNOP})
+	return err == nil
+}
+
+// telnetConn removes telnet commands from the data read and answers option negotiations.
+// Only echo and suppress go ahead are accepted, all other options are refused
+type telnetConn struct {
+	net.Conn
+	state   int
+	command byte
+	replied map[[2]byte]bool
+}
+
+const (
+	telnetStateData = iota
+	telnetStateIAC
+	telnetStateOption
+	telnetStateSB
+	telnetStateSBIAC
+)
+
+func (t *telnetConn) Read(b []byte) (int, error) {
+	for {
+		n, err := t.Conn.Read(b)
+		n = t.filter(b[:n])
+		if n > 0 || err != nil {
+			return n, err
+		}
+	}
+}
+
+// Write escapes IAC bytes and sends line breaks as CR LF
+func (t *telnetConn) Write(b []byte) (int, error) {
+	data := bytes.Replace(b, []byte{telnetIAC}, []byte{telnetIAC, telnetIAC}, -1)
+	data = bytes.Replace(data, []byte("\n"), []byte("\r\n"), -1)
+
+	_, err := t.Conn.Write(data)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(b), nil
+}
+
+// filter removes telnet commands from b in place and returns the length of the remaining data
+func (t *telnetConn) filter(b []byte) int {
+	n := 0
+	for _, c := range b {
+		switch t.state {
+		case telnetStateData:
+			switch c {
+			case telnetIAC:
+				t.state = telnetStateIAC
+			case 0:
+			default:
+				b[n] = c
+				n++
+			}
+		case telnetStateIAC:
+			switch c {
+			case telnetIAC:
+				b[n] = c
+				n++
+				t.state = telnetStateData
+			case telnetWILL, telnetWONT, telnetDO, telnetDONT:
+				t.command = c
+				t.state = telnetStateOption
+			case telnetSB:
+				t.state = telnetStateSB
+			default:
+				t.state = telnetStateData
+			}
+		case telnetStateOption:
+			t.negotiate(t.command, c)
+			t.state = telnetStateData
+		case telnetStateSB:
+			if c == telnetIAC {
+				t.state = telnetStateSBIAC
+			}
+		case telnetStateSBIAC:
+			if c == telnetSE {
+				t.state = telnetStateData
+			} else {
+				t.state = telnetStateSB
+			}
+		}
+	}
+
+	return n
+}
+
+// negotiate answers a request to enable an option. Every request is only answered once to avoid loops
+func (t *telnetConn) negotiate(cmd, option byte) {
+	key := [2]byte{cmd, option}
+	if t.replied[key] {
+		return
+	}
+	if t.replied == nil {
+		t.replied = make(map[[2]byte]bool)
+	}
+	t.replied[key] = true
+
+	var reply byte
+	switch cmd {
+	case telnetDO:
+		reply = telnetWONT
+		if option == telnetOptSGA {
+			reply = telnetWILL
+		}
+	case telnetWILL:
+		reply = telnetDONT
+		if option == telnetOptEcho || option == telnetOptSGA {
+			reply = telnetDO
+		}
+	default:
+		return
+	}
+
+	t.Conn.Write([]byte{telnetIAC, reply, option})
+}
@@ -0,0 +1,220 @@
+package connector
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lwlcom/cisco_exporter/config"
+)
+
+const telnetOptTerminalType = 24
+
+// telnetFake is an in-process telnet server behaving like an IOS device.
+// It negotiates options, asks for username and password and pages the output of show version.
+// The connection is closed when the command hangUp is received
+type telnetFake struct {
+	username string
+	password string
+	hangUp   string
+
+	mu           sync.Mutex
+	negotiations [][2]byte
+	commands     []string
+}
+
+func (f *telnetFake) serve(conn net.Conn) {
+	r := bufio.NewReader(conn)
+	write := func(s string) {
+		conn.Write([]byte(s))
+	}
+
+	conn.Write([]byte{
+		telnetIAC, telnetWILL, telnetOptEcho,
+		telnetIAC, telnetWILL, telnetOptSGA,
+		telnetIAC, telnetDO, telnetOptSGA,
+		telnetIAC, telnetDO, telnetOptTerminalType,
+		telnetIAC, telnetWILL, telnetOptEcho,
+		telnetIAC, telnetSB, telnetOptTerminalType, 1, telnetIAC, telnetSE,
+	})
+	write("\r\n\r\nUser Access Verification\r\n\r\nUsername: ")
+
+	username, err := f.readLine(r)
+	if err != nil {
+		return
+	}
+	write(username + "\r\nPassword: ")
+
+	password, err := f.readLine(r)
+	if err != nil {
+		return
+	}
+	if username != f.username || password != f.password {
+		write("\r\n% Login invalid\r\n\r\nUsername: ")
+		return
+	}
+	write("\r\n\r\nrouter#")
+
+	for {
+		cmd, err := f.readLine(r)
+		if err != nil {
+			return
+		}
+		f.mu.Lock()
+		f.commands = append(f.commands, cmd)
+		f.mu.Unlock()
+
+		if len(f.hangUp) > 0 && cmd == f.hangUp {
+			conn.Close()
+			return
+		}
+		write(cmd + "\r\n")
+		if cmd == "show version" {
+			write("Cisco IOS Software, C3750 Software (C3750-IPSERVICESK9-M), Version 12.2(55)SE10\r\n")
+			conn.Write([]byte{telnetIAC, telnetNOP})
+			write(" --More-- ")
+			if b, err := f.readByte(r); err != nil || b != ' ' {
+				return
+			}
+			write("\x08\x08\x08\x08\x08\x08\x08\x08\x08\x08          \x08\x08\x08\x08\x08\x08\x08\x08\x08\x08")
+			write("router uptime is 1 week, 2 days, 3 hours, 4 minutes\r\n")
+		}
+		write("router#")
+	}
+}
+
+// readByte reads the next data byte and records the option negotiations of the client
+func (f *telnetFake) readByte(r *bufio.Reader) (byte, error) {
+	for {
+		b, err := r.ReadByte()
+		if err != nil || b != telnetIAC {
+			return b, err
+		}
+
+		cmd, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		switch cmd {
+		case telnetIAC:
+			return cmd, nil
+		case telnetWILL, telnetWONT, telnetDO, telnetDONT:
+			opt, err := r.ReadByte()
+			if err != nil {
+				return 0, err
+			}
+			f.mu.Lock()
+			f.negotiations = append(f.negotiations, [2]byte{cmd, opt})
+			f.mu.Unlock()
+		}
+	}
+}
+
+func (f *telnetFake) readLine(r *bufio.Reader) (string, error) {
+	var line []byte
+	for {
+		b, err := f.readByte(r)
+		if err != nil {
+			return "", err
+		}
+		switch b {
+		case '\n':
+			return string(line), nil
+		case '\r':
+		default:
+			line = append(line, b)
+		}
+	}
+}
+
+func testTelnetDevice(t *testing.T, f *telnetFake, username, password string) *Device {
+	host, port, _ := net.SplitHostPort(listen(t, f.serve))
+
+	return &Device{
+		Host:         host,
+		Port:         port,
+		Auth:         AuthByPassword(username, password),
+		DeviceConfig: &config.DeviceConfig{Host: host},
+	}
+}
+
+func TestTelnetConnection(t *testing.T) {
+	f := &telnetFake{username: "exporter", password: "secret"}
+	device := testTelnetDevice(t, f, "exporter", "secret")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c, err := NewTelnetConnection(ctx, device, config.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	out, err := c.RunCommand(ctx, "show version")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "Version 12.2(55)SE10\nrouter uptime is 1 week") {
+		t.Fatalf("pager was not removed from output: %q", out)
+	}
+	if strings.Contains(out, "More") || strings.ContainsAny(out, "\x08\xff\x00") {
+		t.Fatalf("unexpected characters in output: %q", out)
+	}
+
+	if !c.isAlive() {
+		t.Fatal("connection is not alive")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	expected := [][2]byte{
+		{telnetDO, telnetOptEcho},
+		{telnetDO, telnetOptSGA},
+		{telnetWILL, telnetOptSGA},
+		{telnetWONT, telnetOptTerminalType},
+	}
+	if len(f.negotiations) != len(expected) {
+		t.Fatalf("expected negotiations %v, got %v", expected, f.negotiations)
+	}
+	for i := range expected {
+		if f.negotiations[i] != expected[i] {
+			t.Fatalf("expected negotiations %v, got %v", expected, f.negotiations)
+		}
+	}
+
+	if strings.Join(f.commands, ",") != ",terminal length 0,show version" {
+		t.Fatalf("unexpected commands %q", f.commands)
+	}
+}
+
+func TestTelnetConnectionLoginFailed(t *testing.T) {
+	f := &telnetFake{username: "exporter", password: "secret"}
+	device := testTelnetDevice(t, f, "exporter", "wrong")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := NewTelnetConnection(ctx, device, config.New())
+	if err == nil || !strings.Contains(err.Error(), "telnet login failed") {
+		t.Fatalf("expected login error, got %v", err)
+	}
+}
+
+func TestTelnetConnectionClosedAfterLogin(t *testing.T) {
+	f := &telnetFake{username: "exporter", password: "secret", hangUp: "terminal length 0"}
+	device := testTelnetDevice(t, f, "exporter", "secret")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := NewTelnetConnection(ctx, device, config.New())
+	if err == nil || !strings.Contains(err.Error(), "could not disable paging") {
+		t.Fatalf("expected error for closed connection, got %v", err)
+	}
+}
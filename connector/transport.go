@@ -0,0 +1,99 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/lwlcom/cisco_exporter/config"
+	"github.com/pkg/errors"
+)
+
+// Transport is the connection used to get data from a device
+type Transport interface {
+	// Host returns the address of the device
+	Host() string
+
+	// RunCommand runs a command against the device
+	RunCommand(ctx context.Context, cmd string) (string, error)
+
+	// Close closes the connection
+	Close()
+
+	isAlive() bool
+}
+
+// NewConnection connects to device using the transport configured for it
+func NewConnection(ctx context.Context, device *Device, cfg *config.Config) (Transport, error) {
+	switch t := device.DeviceConfig.TransportOrDefault(); t {
+	case config.TransportSSH:
+		return NewSSSHConnection(ctx, device, cfg)
+	case config.TransportTelnet:
+		return NewTelnetConnection(ctx, device, cfg)
+	case config.TransportRestconf:
+		return NewRestconfConnection(ctx, device, cfg)
+	default:
+		return nil, fmt.Errorf("unknown transport %q", t)
+	}
+}
+
+type dialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// dialForDevice gets the function to open the network connection to a device, either directly, through a proxy
+// or tunneled through the jump hosts of the device. The jump client returned has to be released after use
+func dialForDevice(ctx context.Context, device *Device, cfg *config.Config) (dialFunc, *jumpClient, error) {
+	if len(device.ProxyJump) == 0 {
+		dialer, err := dialerForDevice(device, cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		return dialer.DialContext, nil, nil
+	}
+
+	jc, err := jumpClients.acquire(ctx, device.ProxyJump, cfg)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "could not connect to jump host")
+	}
+
+	return jc.dial, jc, nil
+}
+
+// shellConfig is the config of the interactive CLI of a device
+type shellConfig struct {
+	batchSize      int
+	maxOutputSize  int
+	enablePassword *string
+}
+
+func shellConfigForDevice(device *Device, cfg *config.Config) shellConfig {
+	deviceConfig := device.DeviceConfig
+
+	sc := shellConfig{
+		batchSize:     cfg.BatchSize,
+		maxOutputSize: cfg.MaxOutputSize,
+	}
+	if deviceConfig.BatchSize != nil {
+		sc.batchSize = *deviceConfig.BatchSize
+	}
+	if deviceConfig.MaxOutputSize != nil {
+		sc.maxOutputSize = *deviceConfig.MaxOutputSize
+	}
+
+	if deviceConfig.EnablePassword != nil {
+		sc.enablePassword = deviceConfig.EnablePassword
+	} else if len(cfg.EnablePassword) > 0 {
+		sc.enablePassword = &cfg.EnablePassword
+	}
+
+	return sc
+}
+
+func timeoutForDevice(device *Device, cfg *config.Config) time.Duration {
+	timeout := cfg.Timeout
+	if device.DeviceConfig.Timeout != nil {
+		timeout = *device.DeviceConfig.Timeout
+	}
+
+	return time.Duration(timeout) * time.Second
+}
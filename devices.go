@@ -1,19 +1,22 @@
 package main
 
 import (
+	"context"
+	"io/ioutil"
 	"os"
 	"strings"
 
 	"github.com/lwlcom/cisco_exporter/config"
 	"github.com/lwlcom/cisco_exporter/connector"
+	"github.com/lwlcom/cisco_exporter/secrets"
 	"github.com/pkg/errors"
 )
 
-func devicesForConfig(cfg *config.Config) ([]*connector.Device, error) {
+func devicesForConfig(ctx context.Context, cfg *config.Config, store *secrets.Store) ([]*connector.Device, error) {
 	devs := make([]*connector.Device, len(cfg.Devices))
 	var err error
 	for i, d := range cfg.Devices {
-		devs[i], err = deviceFromDeviceConfig(d, cfg)
+		devs[i], err = deviceFromDeviceConfig(ctx, d, cfg, store)
 		if err != nil {
 			return nil, err
 		}
@@ -22,13 +25,45 @@ func devicesForConfig(cfg *config.Config) ([]*connector.Device, error) {
 	return devs, nil
 }
 
-func deviceFromDeviceConfig(device *config.DeviceConfig, cfg *config.Config) (*connector.Device, error) {
-	auth, err := authForDevice(device, cfg)
+func deviceFromDeviceConfig(ctx context.Context, device *config.DeviceConfig, cfg *config.Config, store *secrets.Store) (*connector.Device, error) {
+	device, err := cfg.ResolveDevice(device)
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := deviceForResolvedConfig(ctx, device, cfg, store)
+	if err != nil {
+		return nil, err
+	}
+
+	hops, err := cfg.ResolveProxyJump(device)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not initialize config for device %s", device.Host)
+	}
+	for _, h := range hops {
+		hop, err := deviceForResolvedConfig(ctx, h, cfg, store)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not initialize proxy jump for device %s", device.Host)
+		}
+		d.ProxyJump = append(d.ProxyJump, hop)
+	}
+
+	return d, nil
+}
+
+func deviceForResolvedConfig(ctx context.Context, device *config.DeviceConfig, cfg *config.Config, store *secrets.Store) (*connector.Device, error) {
+	auth, err := authForDevice(ctx, device, cfg, store)
 	if err != nil {
 		return nil, errors.Wrapf(err, "could not initialize config for device %s", device.Host)
 	}
 
 	port := "22"
+	switch device.TransportOrDefault() {
+	case config.TransportTelnet:
+		port = "23"
+	case config.TransportRestconf:
+		port = "443"
+	}
 	host := device.Host
 	if strings.Contains(host, ":") {
 		d := strings.Split(host, ":")
@@ -44,38 +79,157 @@ func deviceFromDeviceConfig(device *config.DeviceConfig, cfg *config.Config) (*c
 	}, nil
 }
 
-func authForDevice(device *config.DeviceConfig, cfg *config.Config) (connector.AuthMethod, error) {
+func authForDevice(ctx context.Context, device *config.DeviceConfig, cfg *config.Config, store *secrets.Store) (connector.AuthMethod, error) {
 	user := cfg.Username
 	if device.Username != nil {
 		user = *device.Username
 	}
 
-	if device.KeyFile != nil {
-		return authForKeyFile(user, *device.KeyFile)
+	if t := device.TransportOrDefault(); t == config.TransportTelnet || t == config.TransportRestconf {
+		password, _, err := passwordForDevice(ctx, device, cfg, store)
+		if err != nil {
+			return nil, err
+		}
+		if len(password) == 0 {
+			return nil, errors.New("no valid authentication method available")
+		}
+		return connector.AuthByPassword(user, password), nil
 	}
 
-	if cfg.KeyFile != "" {
-		return authForKeyFile(user, cfg.KeyFile)
+	methods := cfg.AuthMethods
+	if device.AuthMethods != nil {
+		methods = device.AuthMethods
+	}
+	if len(methods) > 0 {
+		auth := make([]connector.AuthMethod, len(methods))
+		for i, m := range methods {
+			a, err := authMethodForDevice(ctx, m, user, device, cfg, store)
+			if err != nil {
+				return nil, errors.Wrapf(err, "auth method %s", m)
+			}
+			auth[i] = a
+		}
+		return connector.AuthMethods(auth...), nil
 	}
 
-	if device.Password != nil {
-		return connector.AuthByPassword(user, *device.Password), nil
+	if device.KeyFile != nil || cfg.KeyFile != "" {
+		return authMethodForDevice(ctx, config.AuthMethodPublicKey, user, device, cfg, store)
 	}
 
-	if cfg.Password != "" {
-		return connector.AuthByPassword(user, cfg.Password), nil
+	password, found, err := passwordForDevice(ctx, device, cfg, store)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		return connector.AuthByPassword(user, password), nil
 	}
 
 	return nil, errors.New("no valid authentication method available")
 }
 
-func authForKeyFile(username, keyFile string) (connector.AuthMethod, error) {
+func authMethodForDevice(ctx context.Context, method, user string, device *config.DeviceConfig, cfg *config.Config, store *secrets.Store) (connector.AuthMethod, error) {
+	switch method {
+	case config.AuthMethodPublicKey:
+		keyFile := stringOrDefault(device.KeyFile, cfg.KeyFile)
+		if len(keyFile) == 0 {
+			return nil, errors.New("no key file configured")
+		}
+		return authForKeyFile(user, keyFile, stringOrDefault(device.KeyPassphrase, cfg.KeyPassphrase), stringOrDefault(device.CertificateFile, cfg.CertificateFile))
+	case config.AuthMethodAgent:
+		socket := stringOrDefault(device.AgentSocket, cfg.AgentSocket)
+		if len(socket) == 0 {
+			socket = os.Getenv("SSH_AUTH_SOCK")
+		}
+		if len(socket) == 0 {
+			return nil, errors.New("no agent socket configured and SSH_AUTH_SOCK is not set")
+		}
+		return connector.AuthByAgent(user, socket), nil
+	case config.AuthMethodPassword, config.AuthMethodKeyboardInteractive:
+		password, found, err := passwordForDevice(ctx, device, cfg, store)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			return nil, errors.New("no password configured")
+		}
+		if method == config.AuthMethodKeyboardInteractive {
+			return connector.AuthByKeyboardInteractive(user, password), nil
+		}
+		return connector.AuthByPassword(user, password), nil
+	}
+
+	return nil, errors.Errorf("unknown auth method %q", method)
+}
+
+func stringOrDefault(s *string, def string) string {
+	if s != nil {
+		return *s
+	}
+
+	return def
+}
+
+// passwordForDevice gets the password of a device from the config, a password file or the secret store.
+// Settings of the device take precedence over global settings
+func passwordForDevice(ctx context.Context, device *config.DeviceConfig, cfg *config.Config, store *secrets.Store) (string, bool, error) {
+	switch {
+	case device.Password != nil:
+		return *device.Password, true, nil
+	case device.PasswordFile != nil:
+		return readPasswordFile(*device.PasswordFile)
+	case device.PasswordSecret != nil:
+		return resolvePasswordSecret(ctx, *device.PasswordSecret, store)
+	case cfg.Password != "":
+		return cfg.Password, true, nil
+	case cfg.PasswordFile != "":
+		return readPasswordFile(cfg.PasswordFile)
+	case cfg.PasswordSecret != "":
+		return resolvePasswordSecret(ctx, cfg.PasswordSecret, store)
+	}
+
+	return "", false, nil
+}
+
+func readPasswordFile(file string) (string, bool, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", false, errors.Wrap(err, "could not read password file")
+	}
+
+	return strings.TrimRight(string(b), "\r\n"), true, nil
+}
+
+func resolvePasswordSecret(ctx context.Context, ref string, store *secrets.Store) (string, bool, error) {
+	password, err := store.Resolve(ctx, ref)
+	if err != nil {
+		return "", false, errors.Wrap(err, "could not resolve password secret")
+	}
+
+	return password, true, nil
+}
+
+func authForKeyFile(username, keyFile, passphrase, certificateFile string) (connector.AuthMethod, error) {
 	f, err := os.Open(keyFile)
 	if err != nil {
 		return nil, errors.Wrap(err, "could not open ssh key file")
 	}
 	defer f.Close()
-	auth, err := connector.AuthByKey(username, f)
+
+	if len(certificateFile) > 0 {
+		cert, err := os.Open(certificateFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not open ssh certificate file")
+		}
+		defer cert.Close()
+
+		auth, err := connector.AuthByCertificate(username, f, passphrase, cert)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not load ssh certificate")
+		}
+		return auth, nil
+	}
+
+	auth, err := connector.AuthByEncryptedKey(username, f, passphrase)
 	if err != nil {
 		return nil, errors.Wrap(err, "could not load ssh private key file")
 	}
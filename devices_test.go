@@ -0,0 +1,24 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/lwlcom/cisco_exporter/config"
+	"github.com/lwlcom/cisco_exporter/secrets"
+)
+
+func TestAuthForDeviceWithoutPassword(t *testing.T) {
+	for _, transport := range []string{config.TransportTelnet, config.TransportRestconf} {
+		c, err := config.Load(strings.NewReader("devices:\n  - host: router\n    transport: " + transport + "\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = authForDevice(context.Background(), c.Devices[0], c, secrets.NewStore(nil))
+		if err == nil || !strings.Contains(err.Error(), "no valid authentication method") {
+			t.Errorf("%s: expected error without password, got %v", transport, err)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"github.com/lwlcom/cisco_exporter/config"
+	"github.com/lwlcom/cisco_exporter/discovery"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	discoveryManager  *discovery.Manager
+	discoveredDevices []*config.DeviceConfig
+)
+
+// startDiscovery (re)starts service discovery for the config. Has to be called while holding reloadMu
+func startDiscovery(c *config.Config) {
+	if discoveryManager != nil {
+		discoveryManager.Stop()
+		discoveryManager = nil
+	}
+
+	discoverers := discovery.DiscoverersForConfig(c)
+	if len(discoverers) == 0 {
+		discoveredDevices = nil
+		return
+	}
+
+	var m *discovery.Manager
+	m = discovery.NewManager(discoverers, func(devices []*config.DeviceConfig) {
+		updateDiscoveredDevices(m, devices)
+	})
+	discoveryManager = m
+	m.Start()
+}
+
+func updateDiscoveredDevices(m *discovery.Manager, devices []*config.DeviceConfig) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	if m != discoveryManager {
+		return
+	}
+
+	log.Infof("Service discovery found %d devices", len(devices))
+	discoveredDevices = devices
+
+	applyDiscoveredDevices(devices)
+}
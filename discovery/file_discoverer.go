@@ -0,0 +1,75 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	"github.com/lwlcom/cisco_exporter/config"
+	"gopkg.in/yaml.v2"
+)
+
+const defaultFileRefreshInterval = 30 * time.Second
+
+// FileDiscoverer reads devices from JSON or YAML files
+type FileDiscoverer struct {
+	cfg *config.FileSDConfig
+}
+
+// NewFileDiscoverer creates a new file based discoverer
+func NewFileDiscoverer(cfg *config.FileSDConfig) *FileDiscoverer {
+	return &FileDiscoverer{cfg: cfg}
+}
+
+// Name returns an human readable name for logging and debugging purposes
+func (d *FileDiscoverer) Name() string {
+	return fmt.Sprintf("file_sd %v", d.cfg.Files)
+}
+
+// RefreshInterval returns the interval in which the files are checked for changes
+func (d *FileDiscoverer) RefreshInterval() time.Duration {
+	if d.cfg.RefreshInterval > 0 {
+		return d.cfg.RefreshInterval
+	}
+
+	return defaultFileRefreshInterval
+}
+
+// Discover reads the devices from all files matching the configured patterns
+func (d *FileDiscoverer) Discover(ctx context.Context) ([]*config.DeviceConfig, error) {
+	groups := make([]*TargetGroup, 0)
+	for _, pattern := range d.cfg.Files {
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range files {
+			g, err := readTargetGroups(file)
+			if err != nil {
+				return nil, err
+			}
+			groups = append(groups, g...)
+		}
+	}
+
+	return deviceConfigsForGroups(groups)
+}
+
+func readTargetGroups(file string) ([]*TargetGroup, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	// JSON is a subset of YAML, so both formats can be parsed the same way
+	groups := make([]*TargetGroup, 0)
+	err = yaml.Unmarshal(b, &groups)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse %s: %v", file, err)
+	}
+
+	return groups, nil
+}
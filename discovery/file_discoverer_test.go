@@ -0,0 +1,74 @@
+package discovery
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lwlcom/cisco_exporter/config"
+)
+
+func TestFileDiscoverer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "file_sd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	json := `[{"targets": ["host1", "host2:2233"], "labels": {"__cisco_credentials": "core", "__cisco_timeout": "10", "__cisco_feature_optics": "false", "__meta_x": "y", "site": "fra1"}}]`
+	yaml := "- targets: [host3]\n  labels:\n    __cisco_transport: telnet\n"
+	err = ioutil.WriteFile(filepath.Join(dir, "a.json"), []byte(json), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, "b.yml"), []byte(yaml), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewFileDiscoverer(&config.FileSDConfig{Files: []string{filepath.Join(dir, "*.json"), filepath.Join(dir, "*.yml")}})
+	devices, err := d.Discover(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(devices) != 3 {
+		t.Fatalf("expected 3 devices, got %d", len(devices))
+	}
+	if devices[0].Host != "host1" || devices[1].Host != "host2:2233" || devices[2].Host != "host3" {
+		t.Fatalf("unexpected hosts %s, %s, %s", devices[0].Host, devices[1].Host, devices[2].Host)
+	}
+	if devices[0].Credentials != "core" || *devices[0].Timeout != 10 {
+		t.Fatal("config labels were not applied")
+	}
+	if devices[0].Features.Optics.IsEnabled() {
+		t.Fatal("optics should be disabled")
+	}
+	if len(devices[0].Labels) != 1 || devices[0].Labels["site"] != "fra1" {
+		t.Fatalf("unexpected labels %v", devices[0].Labels)
+	}
+	if devices[2].TransportOrDefault() != config.TransportTelnet {
+		t.Fatal("transport label was not applied")
+	}
+}
+
+func TestFileDiscovererInvalidLabel(t *testing.T) {
+	dir, err := ioutil.TempDir("", "file_sd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	err = ioutil.WriteFile(filepath.Join(dir, "a.json"), []byte(`[{"targets": ["host1"], "labels": {"__cisco_timeout": "x"}}]`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewFileDiscoverer(&config.FileSDConfig{Files: []string{filepath.Join(dir, "*.json")}})
+	_, err = d.Discover(context.Background())
+	if err == nil {
+		t.Fatal("expected error for invalid timeout")
+	}
+}
@@ -0,0 +1,69 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/lwlcom/cisco_exporter/config"
+)
+
+const defaultHTTPRefreshInterval = 60 * time.Second
+
+// HTTPDiscoverer polls devices from an HTTP endpoint
+type HTTPDiscoverer struct {
+	cfg    *config.HTTPSDConfig
+	client *http.Client
+}
+
+// NewHTTPDiscoverer creates a new HTTP based discoverer
+func NewHTTPDiscoverer(cfg *config.HTTPSDConfig) *HTTPDiscoverer {
+	d := &HTTPDiscoverer{cfg: cfg}
+	d.client = &http.Client{Timeout: d.RefreshInterval()}
+
+	return d
+}
+
+// Name returns an human readable name for logging and debugging purposes
+func (d *HTTPDiscoverer) Name() string {
+	return "http_sd " + d.cfg.URL
+}
+
+// RefreshInterval returns the interval in which the endpoint is polled
+func (d *HTTPDiscoverer) RefreshInterval() time.Duration {
+	if d.cfg.RefreshInterval > 0 {
+		return d.cfg.RefreshInterval
+	}
+
+	return defaultHTTPRefreshInterval
+}
+
+// Discover gets the devices from the endpoint
+func (d *HTTPDiscoverer) Discover(ctx context.Context) ([]*config.DeviceConfig, error) {
+	req, err := http.NewRequest(http.MethodGet, d.cfg.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	groups := make([]*TargetGroup, 0)
+	err = json.NewDecoder(resp.Body).Decode(&groups)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode response: %v", err)
+	}
+
+	return deviceConfigsForGroups(groups)
+}
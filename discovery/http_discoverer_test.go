@@ -0,0 +1,46 @@
+package discovery
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lwlcom/cisco_exporter/config"
+)
+
+func TestHTTPDiscoverer(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") != "application/json" {
+			t.Errorf("unexpected accept header %q", r.Header.Get("Accept"))
+		}
+		w.Write([]byte(`[{"targets": ["host1"], "labels": {"__cisco_username": "exporter", "role": "edge"}}]`))
+	}))
+	defer srv.Close()
+
+	d := NewHTTPDiscoverer(&config.HTTPSDConfig{URL: srv.URL})
+	devices, err := d.Discover(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(devices) != 1 || devices[0].Host != "host1" {
+		t.Fatalf("unexpected devices %v", devices)
+	}
+	if *devices[0].Username != "exporter" || devices[0].Labels["role"] != "edge" {
+		t.Fatal("labels were not applied")
+	}
+}
+
+func TestHTTPDiscovererError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "failed", http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	d := NewHTTPDiscoverer(&config.HTTPSDConfig{URL: srv.URL})
+	_, err := d.Discover(context.Background())
+	if err == nil {
+		t.Fatal("expected error for status code 500")
+	}
+}
@@ -0,0 +1,111 @@
+package discovery
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/lwlcom/cisco_exporter/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// Discoverer finds devices to scrape
+type Discoverer interface {
+	// Name returns an human readable name for logging and debugging purposes
+	Name() string
+
+	// RefreshInterval returns the interval in which Discover is called
+	RefreshInterval() time.Duration
+
+	// Discover returns the devices currently found
+	Discover(ctx context.Context) ([]*config.DeviceConfig, error)
+}
+
+// Manager runs discoverers and reports the devices found by all of them on every change
+type Manager struct {
+	discoverers []Discoverer
+	results     []([]*config.DeviceConfig)
+	onUpdate    func([]*config.DeviceConfig)
+	mu          sync.Mutex
+	ctx         context.Context
+	cancel      context.CancelFunc
+}
+
+// DiscoverersForConfig creates the discoverers for the service discovery configs
+func DiscoverersForConfig(cfg *config.Config) []Discoverer {
+	discoverers := make([]Discoverer, 0)
+	for _, sd := range cfg.FileSDConfigs {
+		discoverers = append(discoverers, NewFileDiscoverer(sd))
+	}
+	for _, sd := range cfg.HTTPSDConfigs {
+		discoverers = append(discoverers, NewHTTPDiscoverer(sd))
+	}
+
+	return discoverers
+}
+
+// NewManager creates a new manager. onUpdate is called with all devices found whenever they changed
+func NewManager(discoverers []Discoverer, onUpdate func([]*config.DeviceConfig)) *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Manager{
+		discoverers: discoverers,
+		results:     make([]([]*config.DeviceConfig), len(discoverers)),
+		onUpdate:    onUpdate,
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+// Start starts all discoverers
+func (m *Manager) Start() {
+	for i, d := range m.discoverers {
+		go m.run(i, d)
+	}
+}
+
+// Stop stops all discoverers. onUpdate is not called anymore afterwards
+func (m *Manager) Stop() {
+	m.cancel()
+}
+
+func (m *Manager) run(i int, d Discoverer) {
+	ticker := time.NewTicker(d.RefreshInterval())
+	defer ticker.Stop()
+
+	for {
+		devices, err := d.Discover(m.ctx)
+		if err != nil {
+			log.Errorf("%s: %v", d.Name(), err)
+		} else {
+			m.update(i, devices)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-m.ctx.Done():
+			return
+		}
+	}
+}
+
+func (m *Manager) update(i int, devices []*config.DeviceConfig) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.results[i] != nil && reflect.DeepEqual(m.results[i], devices) {
+		return
+	}
+	m.results[i] = devices
+
+	all := make([]*config.DeviceConfig, 0)
+	for _, r := range m.results {
+		all = append(all, r...)
+	}
+
+	if m.ctx.Err() != nil {
+		return
+	}
+	m.onUpdate(all)
+}
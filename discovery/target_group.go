@@ -0,0 +1,111 @@
+package discovery
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lwlcom/cisco_exporter/config"
+)
+
+const (
+	labelPrefix         = "__cisco_"
+	credentialsLabel    = labelPrefix + "credentials"
+	usernameLabel       = labelPrefix + "username"
+	passwordSecretLabel = labelPrefix + "password_secret"
+	keyFileLabel        = labelPrefix + "key_file"
+	timeoutLabel        = labelPrefix + "timeout"
+	legacyCiphersLabel  = labelPrefix + "legacy_ciphers"
+	transportLabel      = labelPrefix + "transport"
+	featureLabelPrefix  = labelPrefix + "feature_"
+)
+
+// TargetGroup is a group of targets in the format used by Prometheus file and HTTP service discovery
+type TargetGroup struct {
+	Targets []string          `json:"targets" yaml:"targets"`
+	Labels  map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+}
+
+// DeviceConfigs creates a device config for every target of the group.
+// Labels starting with __cisco_ set the config of the device, all other labels not starting with __ are set as device labels
+func (g *TargetGroup) DeviceConfigs() ([]*config.DeviceConfig, error) {
+	devices := make([]*config.DeviceConfig, 0, len(g.Targets))
+	for _, target := range g.Targets {
+		d, err := g.deviceConfig(target)
+		if err != nil {
+			return nil, fmt.Errorf("target %s: %v", target, err)
+		}
+		devices = append(devices, d)
+	}
+
+	return devices, nil
+}
+
+func (g *TargetGroup) deviceConfig(target string) (*config.DeviceConfig, error) {
+	d := &config.DeviceConfig{
+		Host: target,
+	}
+
+	for name, value := range g.Labels {
+		v := value
+
+		switch {
+		case name == credentialsLabel:
+			d.Credentials = v
+		case name == usernameLabel:
+			d.Username = &v
+		case name == passwordSecretLabel:
+			d.PasswordSecret = &v
+		case name == keyFileLabel:
+			d.KeyFile = &v
+		case name == timeoutLabel:
+			timeout, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value for %s: %v", name, err)
+			}
+			d.Timeout = &timeout
+		case name == transportLabel:
+			d.Transport = &v
+		case name == legacyCiphersLabel:
+			legacyCiphers, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value for %s: %v", name, err)
+			}
+			d.LegacyCiphers = &legacyCiphers
+		case strings.HasPrefix(name, featureLabelPrefix):
+			enabled, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value for %s: %v", name, err)
+			}
+			if d.Features == nil {
+				d.Features = &config.FeatureConfig{}
+			}
+			err = d.Features.SetFeature(strings.TrimPrefix(name, featureLabelPrefix), config.NewFeature(enabled))
+			if err != nil {
+				return nil, err
+			}
+		case strings.HasPrefix(name, "__"):
+			continue
+		default:
+			if d.Labels == nil {
+				d.Labels = make(map[string]string)
+			}
+			d.Labels[name] = v
+		}
+	}
+
+	return d, nil
+}
+
+func deviceConfigsForGroups(groups []*TargetGroup) ([]*config.DeviceConfig, error) {
+	devices := make([]*config.DeviceConfig, 0)
+	for _, g := range groups {
+		d, err := g.DeviceConfigs()
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, d...)
+	}
+
+	return devices, nil
+}
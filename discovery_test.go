@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lwlcom/cisco_exporter/config"
+	"github.com/lwlcom/cisco_exporter/secrets"
+)
+
+func TestWithDiscoveredDevicesSkipsInvalid(t *testing.T) {
+	c, err := config.Load(strings.NewReader("Password: secret\ndevices:\n  - host: static\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := secrets.NewStore(nil)
+	static, err := devicesForConfig(context.Background(), c, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transport := "carrier-pigeon"
+	discovered := []*config.DeviceConfig{
+		{Host: "valid"},
+		{Host: "static"},
+		{Host: "transport", Transport: &transport},
+		{Host: "credentials", Credentials: "unknown"},
+		{Host: "label", Labels: map[string]string{"target": "x"}},
+	}
+
+	effective, devs := withDiscoveredDevices(c, static, discovered, store)
+
+	if len(devs) != 2 || devs[0].Host != "static" || devs[1].Host != "valid" {
+		hosts := make([]string, len(devs))
+		for i, d := range devs {
+			hosts[i] = d.Host
+		}
+		t.Fatalf("unexpected devices %v", hosts)
+	}
+	if len(effective.Devices) != 2 {
+		t.Fatalf("expected 2 devices in effective config, got %d", len(effective.Devices))
+	}
+	if len(c.Devices) != 1 {
+		t.Fatal("static config was modified")
+	}
+}
+
+func TestBackgroundScraperUpdateDevices(t *testing.T) {
+	c, err := config.Load(strings.NewReader("Password: secret\nscrape_interval: 1h\nfeatures: {bgp: false, environment: false, facts: false, firewall: false, interfaces: false, optics: false}\ndevices:\n  - host: 127.0.0.2:1\n  - host: 127.0.0.3:1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := secrets.NewStore(nil)
+	devs, err := devicesForConfig(context.Background(), c, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg = c
+	limiter = newScrapeLimiter(0)
+
+	s := newBackgroundScraper(devs)
+	s.start()
+	defer s.shutdown()
+
+	s.mu.RLock()
+	kept := s.runs["127.0.0.2"]
+	s.mu.RUnlock()
+	if kept == nil {
+		t.Fatal("device is not scraped")
+	}
+
+	waitForSnapshot(t, s, "127.0.0.2")
+
+	if !s.updateDevices(devs[:1]) {
+		t.Fatal("scraper has to be replaced although label names did not change")
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.runs["127.0.0.2"] != kept {
+		t.Fatal("run of unchanged device was restarted")
+	}
+	if _, found := s.snapshots["127.0.0.2"]; !found {
+		t.Fatal("snapshot of unchanged device was dropped")
+	}
+	if _, found := s.runs["127.0.0.3"]; found {
+		t.Fatal("removed device is still scraped")
+	}
+}
+
+func waitForSnapshot(t *testing.T, s *backgroundScraper, host string) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		s.mu.RLock()
+		_, found := s.snapshots[host]
+		s.mu.RUnlock()
+		if found {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("no snapshot")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestReloadWithoutDiscovery(t *testing.T) {
+	dir, err := ioutil.TempDir("", "reload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	err = ioutil.WriteFile(filepath.Join(dir, "targets.json"), []byte(`[{"targets": ["discovered"]}]`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	static := "Password: secret\ndevices:\n  - host: static\n"
+	writeConfig := func(content string) {
+		err := ioutil.WriteFile(filepath.Join(dir, "config.yml"), []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	previous := *configFile
+	*configFile = filepath.Join(dir, "config.yml")
+	defer func() {
+		*configFile = previous
+		reloadMu.Lock()
+		startDiscovery(&config.Config{})
+		reloadMu.Unlock()
+		connManager.Retain(nil, cfg)
+	}()
+
+	writeConfig(static + "file_sd_configs:\n  - files: [" + filepath.Join(dir, "*.json") + "]\n")
+	if err = reloadConfig(); err != nil {
+		t.Fatal(err)
+	}
+	waitForHosts(t, "static", "discovered")
+
+	writeConfig(static)
+	if err = reloadConfig(); err != nil {
+		t.Fatal(err)
+	}
+	waitForHosts(t, "static")
+}
+
+func waitForHosts(t *testing.T, expected ...string) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		stateMu.RLock()
+		hosts := make([]string, len(devices))
+		for i, d := range devices {
+			hosts[i] = d.Host
+		}
+		stateMu.RUnlock()
+		if strings.Join(hosts, ",") == strings.Join(expected, ",") {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected devices %v, got %v", expected, hosts)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -1,6 +1,8 @@
 package environment
 
 import (
+	"context"
+	"errors"
 	"log"
 
 	"github.com/lwlcom/cisco_exporter/rpc"
@@ -11,24 +13,21 @@ import (
 
 const prefix string = "cisco_environment_"
 
-var (
+type environmentCollector struct {
 	temperaturesDesc *prometheus.Desc
 	powerSupplyDesc  *prometheus.Desc
-)
-
-func init() {
-	l := []string{"target", "item"}
-	temperaturesDesc = prometheus.NewDesc(prefix+"sensor_temp", "Sensor temperatures", l, nil)
-	l = append(l, "status")
-	powerSupplyDesc = prometheus.NewDesc(prefix+"power_up", "Status of power supplies (1 OK, 0 Something is wrong)", l, nil)
 }
 
-type environmentCollector struct {
-}
+// NewCollector creates a new collector. labels are the names of the labels identifying the target
+func NewCollector(labels []string) collector.RPCCollector {
+	c := &environmentCollector{}
+
+	l := append(labels, "item")
+	c.temperaturesDesc = prometheus.NewDesc(prefix+"sensor_temp", "Sensor temperatures", l, nil)
+	l = append(l, "status")
+	c.powerSupplyDesc = prometheus.NewDesc(prefix+"power_up", "Status of power supplies (1 OK, 0 Something is wrong)", l, nil)
 
-// NewCollector creates a new collector
-func NewCollector() collector.RPCCollector {
-	return &environmentCollector{}
+	return c
 }
 
 // Name returns the name of the collector
@@ -37,38 +36,68 @@ func (*environmentCollector) Name() string {
 }
 
 // Describe describes the metrics
-func (*environmentCollector) Describe(ch chan<- *prometheus.Desc) {
-	ch <- temperaturesDesc
-	ch <- powerSupplyDesc
+func (c *environmentCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.temperaturesDesc
+	ch <- c.powerSupplyDesc
+}
+
+// SupportsOper checks if the sensors can be collected from the YANG model
+func (c *environmentCollector) SupportsOper(client *rpc.Client) bool {
+	return client.HasModule(operModule)
 }
 
 // Collect collects metrics from Cisco
-func (c *environmentCollector) Collect(client *rpc.Client, ch chan<- prometheus.Metric, labelValues []string) error {
-	out, err := client.RunCommand("show environment")
-	if err != nil {
-		return err
+func (c *environmentCollector) Collect(ctx context.Context, client *rpc.Client, ch chan<- prometheus.Metric, labelValues []string) error {
+	var items []EnvironmentItem
+	var err error
+	switch {
+	case client.HasModule(operModule):
+		items, err = c.getOper(ctx, client)
+	case client.OSType == rpc.NXOS:
+		items, err = c.getJSON(ctx, client)
+		if errors.Is(err, rpc.ErrNoJSON) {
+			if client.Debug {
+				log.Printf("Parse environment JSON for %s: %s\n", labelValues[0], err.Error())
+			}
+			items, err = c.get(ctx, client, labelValues)
+		}
+	default:
+		items, err = c.get(ctx, client, labelValues)
 	}
-	items, err := c.Parse(client.OSType, out)
 	if err != nil {
-		if client.Debug {
-			log.Printf("Parse environment for %s: %s\n", labelValues[0], err.Error())
-		}
-		return nil
+		return err
 	}
 
 	for _, item := range items {
 		l := append(labelValues, item.Name)
 		if item.IsTemp {
-			ch <- prometheus.MustNewConstMetric(temperaturesDesc, prometheus.GaugeValue, float64(item.Temperature), l...)
+			ch <- prometheus.MustNewConstMetric(c.temperaturesDesc, prometheus.GaugeValue, float64(item.Temperature), l...)
 		} else {
 			val := 0
 			if item.OK {
 				val = 1
 			}
 			l = append(l, item.Status)
-			ch <- prometheus.MustNewConstMetric(powerSupplyDesc, prometheus.GaugeValue, float64(val), l...)
+			ch <- prometheus.MustNewConstMetric(c.powerSupplyDesc, prometheus.GaugeValue, float64(val), l...)
 		}
 	}
 
 	return nil
 }
+
+// get gets temperatures and power supplies from the CLI
+func (c *environmentCollector) get(ctx context.Context, client *rpc.Client, labelValues []string) ([]EnvironmentItem, error) {
+	out, err := client.RunCommand(ctx, "show environment")
+	if err != nil {
+		return nil, err
+	}
+	items, err := c.Parse(client.OSType, out)
+	if err != nil {
+		if client.Debug {
+			log.Printf("Parse environment for %s: %s\n", labelValues[0], err.Error())
+		}
+		return nil, nil
+	}
+
+	return items, nil
+}
@@ -0,0 +1,54 @@
+package environment
+
+import (
+	"context"
+	"strings"
+
+	"github.com/lwlcom/cisco_exporter/rpc"
+)
+
+type environmentJSON struct {
+	Temperatures struct {
+		Rows []struct {
+			Module  string     `json:"tempmod"`
+			Sensor  string     `json:"sensor"`
+			CurTemp rpc.Number `json:"curtemp"`
+		} `json:"ROW_tempinfo"`
+	} `json:"TABLE_tempinfo"`
+	PowerSupplies struct {
+		Table struct {
+			Rows []struct {
+				Number string `json:"psnum"`
+				Status string `json:"ps_status"`
+			} `json:"ROW_psinfo"`
+		} `json:"TABLE_psinfo"`
+	} `json:"powersup"`
+}
+
+// getJSON gets temperatures and power supplies from the JSON output of show environment (NX-OS)
+func (c *environmentCollector) getJSON(ctx context.Context, client *rpc.Client) ([]EnvironmentItem, error) {
+	var data environmentJSON
+	err := client.RunCommandJSON(ctx, "show environment", &data)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []EnvironmentItem{}
+	for _, t := range data.Temperatures.Rows {
+		items = append(items, EnvironmentItem{
+			Name:        strings.TrimSpace(t.Module + " " + t.Sensor),
+			IsTemp:      true,
+			Temperature: float64(t.CurTemp),
+		})
+	}
+	for _, p := range data.PowerSupplies.Table.Rows {
+		status := strings.ToLower(p.Status)
+		items = append(items, EnvironmentItem{
+			Name:   p.Number,
+			OK:     status == "ok" || status == "normal" || status == "good",
+			Status: p.Status,
+		})
+	}
+
+	return items, nil
+}
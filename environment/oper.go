@@ -0,0 +1,56 @@
+package environment
+
+import (
+	"context"
+	"strings"
+
+	"github.com/lwlcom/cisco_exporter/rpc"
+)
+
+const operModule = "Cisco-IOS-XE-environment-oper"
+
+const operFilter = `<environment-sensors xmlns="http://cisco.com/ns/yang/Cisco-IOS-XE-environment-oper"/>`
+
+const operPath = "Cisco-IOS-XE-environment-oper:environment-sensors"
+
+type environmentOper struct {
+	EnvironmentSensors struct {
+		Sensors []struct {
+			Name           string     `xml:"name" json:"name"`
+			Location       string     `xml:"location" json:"location"`
+			State          string     `xml:"state" json:"state"`
+			CurrentReading rpc.Number `xml:"current-reading" json:"current-reading"`
+			SensorUnits    string     `xml:"sensor-units" json:"sensor-units"`
+		} `xml:"environment-sensor" json:"environment-sensor"`
+	} `xml:"environment-sensors" json:"Cisco-IOS-XE-environment-oper:environment-sensors"`
+}
+
+// getOper gets temperatures and power supplies from the Cisco-IOS-XE-environment-oper model.
+// Items are named like in the CLI output (e.g. R0 Inlet, P0 Vin)
+func (c *environmentCollector) getOper(ctx context.Context, client *rpc.Client) ([]EnvironmentItem, error) {
+	var data environmentOper
+	err := client.GetOper(ctx, operFilter, operPath, &data)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []EnvironmentItem{}
+	for _, s := range data.EnvironmentSensors.Sensors {
+		switch {
+		case strings.HasPrefix(s.Name, "Temp: ") && s.SensorUnits == "Celsius":
+			items = append(items, EnvironmentItem{
+				Name:        strings.TrimSpace(s.Location + " " + strings.TrimPrefix(s.Name, "Temp: ")),
+				IsTemp:      true,
+				Temperature: float64(s.CurrentReading),
+			})
+		case strings.HasPrefix(s.Name, "PEM "):
+			items = append(items, EnvironmentItem{
+				Name:   strings.TrimSpace(s.Location + " " + strings.TrimPrefix(s.Name, "PEM ")),
+				OK:     s.State == "Normal",
+				Status: s.State,
+			})
+		}
+	}
+
+	return items, nil
+}
@@ -11,6 +11,12 @@ import (
 
 // Parse parses cli output and tries to find oll temperature and power related data
 func (c *environmentCollector) Parse(ostype string, output string) ([]EnvironmentItem, error) {
+	if ostype == rpc.IOSXR {
+		return c.parseXR(output), nil
+	}
+	if ostype == rpc.ASA {
+		return c.parseASA(output), nil
+	}
 	if ostype != rpc.IOSXE && ostype != rpc.NXOS && ostype != rpc.IOS {
 		return nil, errors.New("'show environment' is not implemented for " + ostype)
 	}
@@ -46,3 +52,78 @@ func (c *environmentCollector) Parse(ostype string, output string) ([]Environmen
 	}
 	return items, nil
 }
+
+// parseXR parses the output of show environment on IOS-XR. Temperatures are listed in a table per location,
+// power modules in a separate table with one row per module
+func (c *environmentCollector) parseXR(output string) []EnvironmentItem {
+	items := []EnvironmentItem{}
+	sectionRegexp := regexp.MustCompile(`^Location\s+(\w+)`)
+	locationRegexp := regexp.MustCompile(`^(\d+/\S+)\s*$`)
+	tempRegexp := regexp.MustCompile(`^\s+(\S+)\s+(-?\d+(?:\.\d+)?)\s+`)
+	powerRegexp := regexp.MustCompile(`^(\d+/PS\d+/\S+)\s+\S+\s+(?:[\d.]+\s+){4}(\S+)\s*$`)
+
+	section := ""
+	location := ""
+	for _, line := range strings.Split(output, "\n") {
+		if matches := sectionRegexp.FindStringSubmatch(line); matches != nil {
+			section = matches[1]
+		} else if matches := powerRegexp.FindStringSubmatch(line); matches != nil {
+			items = append(items, EnvironmentItem{
+				Name:   matches[1],
+				OK:     matches[2] == "OK",
+				Status: matches[2],
+			})
+		} else if matches := locationRegexp.FindStringSubmatch(line); matches != nil {
+			location = matches[1]
+		} else if matches := tempRegexp.FindStringSubmatch(line); matches != nil && section == "TEMPERATURE" {
+			items = append(items, EnvironmentItem{
+				Name:        location + " " + matches[1],
+				IsTemp:      true,
+				Temperature: util.Str2float64(matches[2]),
+			})
+		}
+	}
+
+	return items
+}
+
+// parseASA parses the output of show environment on ASA. The output is divided into sections (e.g. Temperature)
+// with subsections (e.g. Processors), temperatures are listed as "Processor 1: 43.0 C - OK"
+func (c *environmentCollector) parseASA(output string) []EnvironmentItem {
+	items := []EnvironmentItem{}
+	sectionRegexp := regexp.MustCompile(`^(\S.*):\s*$`)
+	subsectionRegexp := regexp.MustCompile(`^  (\S.*):\s*$`)
+	tempRegexp := regexp.MustCompile(`^\s{4}(\S.*?):\s+(-?\d+(?:\.\d+)?) C - (\w+)`)
+	powerRegexp := regexp.MustCompile(`^\s{4}(\S.*?):\s+([A-Za-z].*?)\s*$`)
+
+	section := ""
+	subsection := ""
+	power := make(map[string]bool)
+	for _, line := range strings.Split(output, "\n") {
+		if matches := sectionRegexp.FindStringSubmatch(line); matches != nil {
+			section = matches[1]
+			subsection = ""
+		} else if matches := subsectionRegexp.FindStringSubmatch(line); matches != nil {
+			subsection = matches[1]
+		} else if matches := tempRegexp.FindStringSubmatch(line); matches != nil {
+			items = append(items, EnvironmentItem{
+				Name:        strings.TrimSpace(subsection + " " + matches[1]),
+				IsTemp:      true,
+				Temperature: util.Str2float64(matches[2]),
+			})
+		} else if matches := powerRegexp.FindStringSubmatch(line); matches != nil && section == "Power Supplies" {
+			// the status of a power supply can be listed in more than one subsection
+			if power[matches[1]] {
+				continue
+			}
+			power[matches[1]] = true
+			items = append(items, EnvironmentItem{
+				Name:   matches[1],
+				OK:     matches[2] == "OK" || matches[2] == "Present",
+				Status: matches[2],
+			})
+		}
+	}
+
+	return items
+}
@@ -12,8 +12,45 @@ type MemoryFact struct {
 }
 
 type CPUFact struct {
-	FiveSeconds float64
-	Interrupts  float64
-	OneMinute   float64
-	FiveMinutes float64
+	FiveSeconds        float64
+	Interrupts         float64
+	OneMinute          float64
+	FiveMinutes        float64
+	User               float64
+	Kernel             float64
+	Idle               float64
+	LoadOneMinute      float64
+	LoadFiveMinutes    float64
+	LoadFifteenMinutes float64
+}
+
+// newCPUFact creates a CPUFact with all values set to -1, which marks values not provided by the OS
+func newCPUFact() CPUFact {
+	return CPUFact{
+		FiveSeconds:        -1,
+		Interrupts:         -1,
+		OneMinute:          -1,
+		FiveMinutes:        -1,
+		User:               -1,
+		Kernel:             -1,
+		Idle:               -1,
+		LoadOneMinute:      -1,
+		LoadFiveMinutes:    -1,
+		LoadFifteenMinutes: -1,
+	}
+}
+
+type InfoFact struct {
+	Hostname       string
+	Model          string
+	Serial         string
+	Image          string
+	ConfigRegister string
+	Uptime         float64
+	ReloadReason   string
+}
+
+type ChassisFact struct {
+	Model  string
+	Serial string
 }
@@ -1,6 +1,8 @@
 package facts
 
 import (
+	"context"
+	"errors"
 	"log"
 
 	"github.com/lwlcom/cisco_exporter/rpc"
@@ -11,37 +13,53 @@ import (
 
 const prefix string = "cisco_facts_"
 
-var (
-	versionDesc        *prometheus.Desc
-	memoryTotalDesc    *prometheus.Desc
-	memoryUsedDesc     *prometheus.Desc
-	memoryFreeDesc     *prometheus.Desc
-	cpuOneMinuteDesc   *prometheus.Desc
-	cpuFiveSecondsDesc *prometheus.Desc
-	cpuInterruptsDesc  *prometheus.Desc
-	cpuFiveMinutesDesc *prometheus.Desc
-)
+type factsCollector struct {
+	versionDesc            *prometheus.Desc
+	infoDesc               *prometheus.Desc
+	uptimeDesc             *prometheus.Desc
+	reloadReasonDesc       *prometheus.Desc
+	memoryTotalDesc        *prometheus.Desc
+	memoryUsedDesc         *prometheus.Desc
+	memoryFreeDesc         *prometheus.Desc
+	cpuOneMinuteDesc       *prometheus.Desc
+	cpuFiveSecondsDesc     *prometheus.Desc
+	cpuInterruptsDesc      *prometheus.Desc
+	cpuFiveMinutesDesc     *prometheus.Desc
+	cpuUserDesc            *prometheus.Desc
+	cpuKernelDesc          *prometheus.Desc
+	cpuIdleDesc            *prometheus.Desc
+	loadOneMinuteDesc      *prometheus.Desc
+	loadFiveMinutesDesc    *prometheus.Desc
+	loadFifteenMinutesDesc *prometheus.Desc
+}
 
-func init() {
-	l := []string{"target"}
-	versionDesc = prometheus.NewDesc(prefix+"version", "Running OS version", append(l, "version"), nil)
+// NewCollector creates a new collector. labels are the names of the labels identifying the target
+func NewCollector(labels []string) collector.RPCCollector {
+	c := &factsCollector{}
 
-	memoryTotalDesc = prometheus.NewDesc(prefix+"memory_total", "Total memory", append(l, "type"), nil)
-	memoryUsedDesc = prometheus.NewDesc(prefix+"memory_used", "Used memory", append(l, "type"), nil)
-	memoryFreeDesc = prometheus.NewDesc(prefix+"memory_free", "Free memory", append(l, "type"), nil)
+	l := labels
+	c.versionDesc = prometheus.NewDesc(prefix+"version", "Running OS version", append(l, "version"), nil)
+	c.infoDesc = prometheus.NewDesc(prefix+"info", "Device informations", append(l, "hostname", "model", "serial", "image", "config_register"), nil)
+	c.uptimeDesc = prometheus.NewDesc(prefix+"uptime_seconds", "Uptime in seconds", l, nil)
+	c.reloadReasonDesc = prometheus.NewDesc(prefix+"last_reload_info", "Reason of the last reload", append(l, "reason"), nil)
 
-	cpuOneMinuteDesc = prometheus.NewDesc(prefix+"cpu_one_minute_percent", "CPU utilization for one minute", l, nil)
-	cpuFiveSecondsDesc = prometheus.NewDesc(prefix+"cpu_five_seconds_percent", "CPU utilization for five seconds", l, nil)
-	cpuInterruptsDesc = prometheus.NewDesc(prefix+"cpu_interrupt_percent", "Interrupt percentage", l, nil)
-	cpuFiveMinutesDesc = prometheus.NewDesc(prefix+"cpu_five_minutes_percent", "CPU utilization for five minutes", l, nil)
-}
+	c.memoryTotalDesc = prometheus.NewDesc(prefix+"memory_total", "Total memory", append(l, "type"), nil)
+	c.memoryUsedDesc = prometheus.NewDesc(prefix+"memory_used", "Used memory", append(l, "type"), nil)
+	c.memoryFreeDesc = prometheus.NewDesc(prefix+"memory_free", "Free memory", append(l, "type"), nil)
 
-type factsCollector struct {
-}
+	c.cpuOneMinuteDesc = prometheus.NewDesc(prefix+"cpu_one_minute_percent", "CPU utilization for one minute", l, nil)
+	c.cpuFiveSecondsDesc = prometheus.NewDesc(prefix+"cpu_five_seconds_percent", "CPU utilization for five seconds", l, nil)
+	c.cpuInterruptsDesc = prometheus.NewDesc(prefix+"cpu_interrupt_percent", "Interrupt percentage", l, nil)
+	c.cpuFiveMinutesDesc = prometheus.NewDesc(prefix+"cpu_five_minutes_percent", "CPU utilization for five minutes", l, nil)
+	c.cpuUserDesc = prometheus.NewDesc(prefix+"cpu_user_percent", "CPU utilization in user mode", l, nil)
+	c.cpuKernelDesc = prometheus.NewDesc(prefix+"cpu_kernel_percent", "CPU utilization in kernel mode", l, nil)
+	c.cpuIdleDesc = prometheus.NewDesc(prefix+"cpu_idle_percent", "CPU idle percentage", l, nil)
 
-// NewCollector creates a new collector
-func NewCollector() collector.RPCCollector {
-	return &factsCollector{}
+	c.loadOneMinuteDesc = prometheus.NewDesc(prefix+"load_one_minute", "Load average for one minute", l, nil)
+	c.loadFiveMinutesDesc = prometheus.NewDesc(prefix+"load_five_minutes", "Load average for five minutes", l, nil)
+	c.loadFifteenMinutesDesc = prometheus.NewDesc(prefix+"load_fifteen_minutes", "Load average for fifteen minutes", l, nil)
+
+	return c
 }
 
 // Name returns the name of the collector
@@ -50,77 +68,353 @@ func (*factsCollector) Name() string {
 }
 
 // Describe describes the metrics
-func (*factsCollector) Describe(ch chan<- *prometheus.Desc) {
-	ch <- versionDesc
-	ch <- memoryTotalDesc
-	ch <- memoryUsedDesc
-	ch <- memoryFreeDesc
+func (c *factsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.versionDesc
+	ch <- c.infoDesc
+	ch <- c.uptimeDesc
+	ch <- c.reloadReasonDesc
+	ch <- c.memoryTotalDesc
+	ch <- c.memoryUsedDesc
+	ch <- c.memoryFreeDesc
+	ch <- c.cpuOneMinuteDesc
+	ch <- c.cpuFiveSecondsDesc
+	ch <- c.cpuInterruptsDesc
+	ch <- c.cpuFiveMinutesDesc
+	ch <- c.cpuUserDesc
+	ch <- c.cpuKernelDesc
+	ch <- c.cpuIdleDesc
+	ch <- c.loadOneMinuteDesc
+	ch <- c.loadFiveMinutesDesc
+	ch <- c.loadFifteenMinutesDesc
 }
 
-// CollectVersion collects version informations from Cisco
-func (c *factsCollector) CollectVersion(client *rpc.Client, ch chan<- prometheus.Metric, labelValues []string) error {
-	out, err := client.RunCommand("show version")
+// commandCache runs every command at most once per scrape, as several facts are taken from the same output
+// (e.g. version and device informations from show version)
+type commandCache struct {
+	results map[string]commandResult
+}
+
+type commandResult struct {
+	value interface{}
+	err   error
+}
+
+func newCommandCache() *commandCache {
+	return &commandCache{results: make(map[string]commandResult)}
+}
+
+func (cc *commandCache) get(key string, run func() (interface{}, error)) (interface{}, error) {
+	r, found := cc.results[key]
+	if !found {
+		r.value, r.err = run()
+		cc.results[key] = r
+	}
+	return r.value, r.err
+}
+
+// text gets the text output of cmd
+func (cc *commandCache) text(ctx context.Context, client *rpc.Client, cmd string) (string, error) {
+	v, err := cc.get(cmd, func() (interface{}, error) {
+		return client.RunCommand(ctx, cmd)
+	})
 	if err != nil {
-		return err
+		return "", err
+	}
+	return v.(string), nil
+}
+
+// versionJSON gets the JSON output of show version (NX-OS)
+func (cc *commandCache) versionJSON(ctx context.Context, client *rpc.Client) (*versionJSON, error) {
+	v, err := cc.get("show version | json", func() (interface{}, error) {
+		var data versionJSON
+		return &data, client.RunCommandJSON(ctx, "show version", &data)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*versionJSON), nil
+}
+
+// systemResourcesJSON gets the JSON output of show system resources (NX-OS)
+func (cc *commandCache) systemResourcesJSON(ctx context.Context, client *rpc.Client) (*systemResourcesJSON, error) {
+	v, err := cc.get("show system resources | json", func() (interface{}, error) {
+		var data systemResourcesJSON
+		return &data, client.RunCommandJSON(ctx, "show system resources", &data)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*systemResourcesJSON), nil
+}
+
+// CollectVersion collects version informations from Cisco
+func (c *factsCollector) CollectVersion(ctx context.Context, client *rpc.Client, cmds *commandCache, ch chan<- prometheus.Metric, labelValues []string) error {
+	var item VersionFact
+	var err error
+	switch {
+	case client.HasModule(hardwareOperModule):
+		item, err = c.getVersionOper(ctx, client)
+	case c.hasVersionOper(client):
+		item, err = c.getVersionNxos(ctx, client)
+	default:
+		item, err = c.getVersion(ctx, client, cmds, labelValues)
 	}
-	item, err := c.ParseVersion(client.OSType, out)
 	if err != nil {
 		return err
 	}
 	l := append(labelValues, item.Version)
-	ch <- prometheus.MustNewConstMetric(versionDesc, prometheus.GaugeValue, 1, l...)
+	ch <- prometheus.MustNewConstMetric(c.versionDesc, prometheus.GaugeValue, 1, l...)
 	return nil
 }
 
-// CollectMemory collects memory informations from Cisco
-func (c *factsCollector) CollectMemory(client *rpc.Client, ch chan<- prometheus.Metric, labelValues []string) error {
-	out, err := client.RunCommand("show process memory")
+// getVersion gets the version from show version
+func (c *factsCollector) getVersion(ctx context.Context, client *rpc.Client, cmds *commandCache, labelValues []string) (VersionFact, error) {
+	if client.OSType == rpc.NXOS {
+		data, err := cmds.versionJSON(ctx, client)
+		if !errors.Is(err, rpc.ErrNoJSON) {
+			if err != nil {
+				return VersionFact{}, err
+			}
+			return c.versionFromJSON(client.OSType, data)
+		}
+		if client.Debug {
+			log.Printf("Parse show version JSON for %s: %s\n", labelValues[0], err.Error())
+		}
+	}
+
+	out, err := cmds.text(ctx, client, "show version")
+	if err != nil {
+		return VersionFact{}, err
+	}
+	return c.ParseVersion(client.OSType, out)
+}
+
+// CollectInfo collects hostname, model, serial, image, config register, uptime and last reload reason from Cisco
+func (c *factsCollector) CollectInfo(ctx context.Context, client *rpc.Client, cmds *commandCache, ch chan<- prometheus.Metric, labelValues []string) error {
+	if client.OSType != rpc.IOSXE && client.OSType != rpc.NXOS && client.OSType != rpc.IOS {
+		return errors.New("device informations are not implemented for " + client.OSType)
+	}
+	item, err := c.getInfo(ctx, client, cmds, labelValues)
 	if err != nil {
 		return err
 	}
-	items, err := c.ParseMemory(client.OSType, out)
+	// model and serial of the chassis are only taken from show inventory if show version has none
+	if len(item.Model) == 0 || len(item.Serial) == 0 {
+		chassis, err := c.getChassis(ctx, client, labelValues)
+		if err == nil {
+			if len(item.Model) == 0 {
+				item.Model = chassis.Model
+			}
+			if len(item.Serial) == 0 {
+				item.Serial = chassis.Serial
+			}
+		} else if client.Debug {
+			log.Printf("Parse inventory for %s: %s\n", labelValues[0], err.Error())
+		}
+	}
+
+	l := append(labelValues, item.Hostname, item.Model, item.Serial, item.Image, item.ConfigRegister)
+	ch <- prometheus.MustNewConstMetric(c.infoDesc, prometheus.GaugeValue, 1, l...)
+	if item.Uptime >= 0 {
+		ch <- prometheus.MustNewConstMetric(c.uptimeDesc, prometheus.GaugeValue, item.Uptime, labelValues...)
+	}
+	if len(item.ReloadReason) > 0 {
+		ch <- prometheus.MustNewConstMetric(c.reloadReasonDesc, prometheus.GaugeValue, 1, append(labelValues, item.ReloadReason)...)
+	}
+	return nil
+}
+
+// getInfo gets the device informations from show version
+func (c *factsCollector) getInfo(ctx context.Context, client *rpc.Client, cmds *commandCache, labelValues []string) (InfoFact, error) {
+	if client.OSType == rpc.NXOS {
+		data, err := cmds.versionJSON(ctx, client)
+		if !errors.Is(err, rpc.ErrNoJSON) {
+			if err != nil {
+				return InfoFact{}, err
+			}
+			return c.infoFromJSON(data)
+		}
+		if client.Debug {
+			log.Printf("Parse show version JSON for %s: %s\n", labelValues[0], err.Error())
+		}
+	}
+
+	out, err := cmds.text(ctx, client, "show version")
+	if err != nil {
+		return InfoFact{}, err
+	}
+	return c.ParseInfo(client.OSType, out)
+}
+
+// getChassis gets model and serial of the chassis from show inventory
+func (c *factsCollector) getChassis(ctx context.Context, client *rpc.Client, labelValues []string) (ChassisFact, error) {
+	if client.OSType == rpc.NXOS {
+		item, err := c.getChassisJSON(ctx, client)
+		if !errors.Is(err, rpc.ErrNoJSON) {
+			return item, err
+		}
+		if client.Debug {
+			log.Printf("Parse show inventory JSON for %s: %s\n", labelValues[0], err.Error())
+		}
+	}
+
+	out, err := client.RunCommand(ctx, "show inventory")
+	if err != nil {
+		return ChassisFact{}, err
+	}
+	return c.ParseInventory(out)
+}
+
+// CollectMemory collects memory informations from Cisco
+func (c *factsCollector) CollectMemory(ctx context.Context, client *rpc.Client, cmds *commandCache, ch chan<- prometheus.Metric, labelValues []string) error {
+	var items []MemoryFact
+	var err error
+	switch {
+	case client.HasModule(memoryOperModule):
+		items, err = c.getMemoryOper(ctx, client)
+	case client.OSType == rpc.NXOS:
+		items, err = c.getMemoryJSON(ctx, client, cmds)
+		if errors.Is(err, rpc.ErrNoJSON) {
+			if client.Debug {
+				log.Printf("Parse memory JSON for %s: %s\n", labelValues[0], err.Error())
+			}
+			items, err = c.getMemory(ctx, client, cmds)
+		}
+	default:
+		items, err = c.getMemory(ctx, client, cmds)
+	}
 	if err != nil {
 		return err
 	}
 	for _, item := range items {
 		l := append(labelValues, item.Type)
-		ch <- prometheus.MustNewConstMetric(memoryTotalDesc, prometheus.GaugeValue, item.Total, l...)
-		ch <- prometheus.MustNewConstMetric(memoryUsedDesc, prometheus.GaugeValue, item.Used, l...)
-		ch <- prometheus.MustNewConstMetric(memoryFreeDesc, prometheus.GaugeValue, item.Free, l...)
+		ch <- prometheus.MustNewConstMetric(c.memoryTotalDesc, prometheus.GaugeValue, item.Total, l...)
+		ch <- prometheus.MustNewConstMetric(c.memoryUsedDesc, prometheus.GaugeValue, item.Used, l...)
+		ch <- prometheus.MustNewConstMetric(c.memoryFreeDesc, prometheus.GaugeValue, item.Free, l...)
 	}
 	return nil
 }
 
-// CollectCPU collects cpu informations from Cisco
-func (c *factsCollector) CollectCPU(client *rpc.Client, ch chan<- prometheus.Metric, labelValues []string) error {
-	out, err := client.RunCommand("show process cpu")
+// getMemory gets the memory pools from the CLI
+func (c *factsCollector) getMemory(ctx context.Context, client *rpc.Client, cmds *commandCache) ([]MemoryFact, error) {
+	cmd := "show process memory"
+	switch client.OSType {
+	case rpc.IOSXR:
+		cmd = "show processes memory summary"
+	case rpc.NXOS:
+		cmd = "show system resources"
+	case rpc.ASA:
+		cmd = "show memory"
+	}
+	out, err := cmds.text(ctx, client, cmd)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	return c.ParseMemory(client.OSType, out)
+}
+
+// CollectCPU collects cpu informations from Cisco
+func (c *factsCollector) CollectCPU(ctx context.Context, client *rpc.Client, cmds *commandCache, ch chan<- prometheus.Metric, labelValues []string) error {
+	var item CPUFact
+	var err error
+	switch {
+	case client.HasModule(cpuOperModule):
+		item, err = c.getCPUOper(ctx, client)
+	case client.OSType == rpc.NXOS:
+		item, err = c.getCPUJSON(ctx, client, cmds)
+		if errors.Is(err, rpc.ErrNoJSON) {
+			if client.Debug {
+				log.Printf("Parse cpu JSON for %s: %s\n", labelValues[0], err.Error())
+			}
+			item, err = c.getCPU(ctx, client, cmds)
+		}
+	default:
+		item, err = c.getCPU(ctx, client, cmds)
 	}
-	item, err := c.ParseCPU(client.OSType, out)
 	if err != nil {
 		return err
 	}
-	ch <- prometheus.MustNewConstMetric(cpuOneMinuteDesc, prometheus.GaugeValue, item.OneMinute, labelValues...)
-	ch <- prometheus.MustNewConstMetric(cpuFiveSecondsDesc, prometheus.GaugeValue, item.FiveSeconds, labelValues...)
-	ch <- prometheus.MustNewConstMetric(cpuInterruptsDesc, prometheus.GaugeValue, item.Interrupts, labelValues...)
-	ch <- prometheus.MustNewConstMetric(cpuFiveMinutesDesc, prometheus.GaugeValue, item.FiveMinutes, labelValues...)
+	// values not provided by the OS are -1: IOS-XR has no five seconds and interrupt utilization, ASA no interrupt
+	// utilization and NX-OS only load averages and CPU states
+	metrics := []struct {
+		desc  *prometheus.Desc
+		value float64
+	}{
+		{c.cpuOneMinuteDesc, item.OneMinute},
+		{c.cpuFiveSecondsDesc, item.FiveSeconds},
+		{c.cpuInterruptsDesc, item.Interrupts},
+		{c.cpuFiveMinutesDesc, item.FiveMinutes},
+		{c.cpuUserDesc, item.User},
+		{c.cpuKernelDesc, item.Kernel},
+		{c.cpuIdleDesc, item.Idle},
+		{c.loadOneMinuteDesc, item.LoadOneMinute},
+		{c.loadFiveMinutesDesc, item.LoadFiveMinutes},
+		{c.loadFifteenMinutesDesc, item.LoadFifteenMinutes},
+	}
+	for _, m := range metrics {
+		if m.value >= 0 {
+			ch <- prometheus.MustNewConstMetric(m.desc, prometheus.GaugeValue, m.value, labelValues...)
+		}
+	}
 	return nil
 }
 
+// getCPU gets the CPU utilization from the CLI
+func (c *factsCollector) getCPU(ctx context.Context, client *rpc.Client, cmds *commandCache) (CPUFact, error) {
+	cmd := "show process cpu"
+	switch client.OSType {
+	case rpc.IOSXR:
+		cmd = "show processes cpu"
+	case rpc.NXOS:
+		cmd = "show system resources"
+	case rpc.ASA:
+		cmd = "show cpu usage"
+	}
+	out, err := cmds.text(ctx, client, cmd)
+	if err != nil {
+		return CPUFact{}, err
+	}
+	return c.ParseCPU(client.OSType, out)
+}
+
+// SupportsOper checks if any of the facts can be collected from the YANG models
+func (c *factsCollector) SupportsOper(client *rpc.Client) bool {
+	return c.hasVersionOper(client) || client.HasModule(memoryOperModule) || client.HasModule(cpuOperModule)
+}
+
+func (c *factsCollector) hasVersionOper(client *rpc.Client) bool {
+	return client.HasModule(hardwareOperModule) || client.Restconf != nil && client.HasModule(nxosDeviceModule)
+}
+
 // Collect collects metrics from Cisco
-func (c *factsCollector) Collect(client *rpc.Client, ch chan<- prometheus.Metric, labelValues []string) error {
-	err := c.CollectVersion(client, ch, labelValues)
-	if client.Debug && err != nil {
-		log.Printf("CollectVersion for %s: %s\n", labelValues[0], err.Error())
-	}
-	err = c.CollectMemory(client, ch, labelValues)
-	if client.Debug && err != nil {
-		log.Printf("CollectMemory for %s: %s\n", labelValues[0], err.Error())
-	}
-	err = c.CollectCPU(client, ch, labelValues)
-	if client.Debug && err != nil {
-		log.Printf("CollectCPU for %s: %s\n", labelValues[0], err.Error())
+func (c *factsCollector) Collect(ctx context.Context, client *rpc.Client, ch chan<- prometheus.Metric, labelValues []string) error {
+	// without CLI (RESTCONF) only the facts available from the YANG models of the device are collected
+	cli := client.Restconf == nil
+	cmds := newCommandCache()
+
+	if cli || c.hasVersionOper(client) {
+		err := c.CollectVersion(ctx, client, cmds, ch, labelValues)
+		if client.Debug && err != nil {
+			log.Printf("CollectVersion for %s: %s\n", labelValues[0], err.Error())
+		}
+	}
+	if cli {
+		err := c.CollectInfo(ctx, client, cmds, ch, labelValues)
+		if client.Debug && err != nil {
+			log.Printf("CollectInfo for %s: %s\n", labelValues[0], err.Error())
+		}
+	}
+	if cli || client.HasModule(memoryOperModule) {
+		err := c.CollectMemory(ctx, client, cmds, ch, labelValues)
+		if client.Debug && err != nil {
+			log.Printf("CollectMemory for %s: %s\n", labelValues[0], err.Error())
+		}
+	}
+	if cli || client.HasModule(cpuOperModule) {
+		err := c.CollectCPU(ctx, client, cmds, ch, labelValues)
+		if client.Debug && err != nil {
+			log.Printf("CollectCPU for %s: %s\n", labelValues[0], err.Error())
+		}
 	}
 	return nil
 }
@@ -0,0 +1,200 @@
+package facts
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/lwlcom/cisco_exporter/connector"
+	"github.com/lwlcom/cisco_exporter/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// fakeTransport answers commands with fixed outputs and counts the commands run
+type fakeTransport struct {
+	connector.Transport
+	outputs  map[string]string
+	commands map[string]int
+}
+
+func (t *fakeTransport) Host() string { return "fake" }
+
+func (t *fakeTransport) RunCommand(ctx context.Context, cmd string) (string, error) {
+	t.commands[cmd]++
+	return t.outputs[cmd], nil
+}
+
+const showVersionIOS = `Cisco IOS Software, C3750E Software (C3750E-UNIVERSALK9-M), Version 15.0(2)SE11, RELEASE SOFTWARE (fc3)
+Technical Support: http://www.cisco.com/techsupport
+
+ROM: Bootstrap program is C3750E boot loader
+
+sw-access-01 uptime is 1 year, 2 weeks, 3 days, 4 hours, 5 minutes
+System returned to ROM by power-on
+System image file is "flash:/c3750e-universalk9-mz.150-2.SE11.bin"
+
+cisco WS-C3750X-48P (PowerPC405) processor (revision W0) with 262144K bytes of memory.
+Processor board ID FDO1234X5YZ
+Configuration register is 0xF
+`
+
+const showVersionNxosJSON = `{
+  "nxos_ver_str": "9.3(8)",
+  "host_name": "nx-core-01",
+  "chassis_id": "Nexus9000 C93180YC-EX chassis",
+  "proc_board_id": "FDO21120U8N",
+  "nxos_file_name": "bootflash:///nxos.9.3.8.bin",
+  "kern_uptm_days": "10",
+  "kern_uptm_hrs": "2",
+  "kern_uptm_mins": "30",
+  "kern_uptm_secs": "15",
+  "rr_reason": "Reset Requested by CLI command reload"
+}
+`
+
+// scrape runs a collector against a client when registered
+type scrape struct {
+	collector *factsCollector
+	client    *rpc.Client
+}
+
+func (s *scrape) Describe(ch chan<- *prometheus.Desc) {
+	s.collector.Describe(ch)
+}
+
+func (s *scrape) Collect(ch chan<- prometheus.Metric) {
+	s.collector.Collect(context.Background(), s.client, ch, []string{"sw"})
+}
+
+// collect returns the labels and the value of the metrics by name
+func collect(t *testing.T, ostype string, outputs map[string]string) (map[string]map[string]string, map[string]int) {
+	conn := &fakeTransport{outputs: outputs, commands: make(map[string]int)}
+	client := rpc.NewClient(conn, false)
+	client.OSType = ostype
+	client.NoJSON = make(map[string]bool)
+
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(&scrape{collector: NewCollector([]string{"target"}).(*factsCollector), client: client})
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	metrics := make(map[string]map[string]string)
+	for _, f := range families {
+		for _, m := range f.Metric {
+			labels := map[string]string{"value": fmt.Sprint(m.GetGauge().GetValue())}
+			for _, l := range m.Label {
+				labels[l.GetName()] = l.GetValue()
+			}
+			metrics[f.GetName()] = labels
+		}
+	}
+
+	return metrics, conn.commands
+}
+
+func TestCollectInfoFromOneShowVersion(t *testing.T) {
+	metrics, commands := collect(t, rpc.IOS, map[string]string{"show version": showVersionIOS})
+
+	if commands["show version"] != 1 {
+		t.Fatalf("expected show version to run once, ran %d times", commands["show version"])
+	}
+	if commands["show inventory"] != 0 {
+		t.Fatal("show inventory run although show version has model and serial")
+	}
+
+	info := metrics[prefix+"info"]
+	if info["model"] != "WS-C3750X-48P" || info["serial"] != "FDO1234X5YZ" || info["hostname"] != "sw-access-01" || info["config_register"] != "0xF" {
+		t.Fatalf("unexpected info %v", info)
+	}
+	if v := metrics[prefix+"version"]; v["version"] != "IOS-15.0(2)SE11" {
+		t.Fatalf("unexpected version %v", v)
+	}
+	if r := metrics[prefix+"last_reload_info"]; r["reason"] != "power-on" {
+		t.Fatalf("unexpected reload reason %v", r)
+	}
+	if u := metrics[prefix+"uptime_seconds"]; u["value"] != "3.30195e+07" {
+		t.Fatalf("unexpected uptime %v", u)
+	}
+}
+
+func TestCollectInfoWithInventory(t *testing.T) {
+	version := strings.Replace(showVersionIOS, "Processor board ID FDO1234X5YZ\n", "", 1)
+	_, commands := collect(t, rpc.IOS, map[string]string{
+		"show version":   version,
+		"show inventory": `NAME: "1", DESCR: "WS-C3750X-48P"` + "\n" + `PID: WS-C3750X-48P-S  , VID: V05  , SN: FDO1234X5YZ`,
+	})
+
+	if commands["show version"] != 1 || commands["show inventory"] != 1 {
+		t.Fatalf("unexpected commands %v", commands)
+	}
+}
+
+func TestCollectInfoNxosJSON(t *testing.T) {
+	metrics, commands := collect(t, rpc.NXOS, map[string]string{"show version | json": showVersionNxosJSON})
+
+	if commands["show version | json"] != 1 || commands["show version"] != 0 || commands["show inventory | json"] != 0 {
+		t.Fatalf("unexpected commands %v", commands)
+	}
+	if v := metrics[prefix+"version"]; v["version"] != "NXOS-9.3(8)" {
+		t.Fatalf("unexpected version %v", v)
+	}
+	if info := metrics[prefix+"info"]; info["model"] != "Nexus9000 C93180YC-EX" {
+		t.Fatalf("unexpected info %v", info)
+	}
+}
+
+const showSystemResourcesNxos = `Load average:   1 minute: 0.45   5 minutes: 0.50   15 minutes: 0.52
+Processes   :   785 total, 1 running
+CPU states  :   3.10% user,   1.20% kernel,   95.70% idle
+Memory usage:   24632252K total,   13887132K used,   10745120K free
+`
+
+const showSystemResourcesNxosJSON = `{
+  "load_avg_1min": "0.45",
+  "load_avg_5min": "0.50",
+  "load_avg_15min": "0.52",
+  "cpu_state_user": "3.10",
+  "cpu_state_kernel": "1.20",
+  "cpu_state_idle": "95.70",
+  "memory_usage_total": "24632252",
+  "memory_usage_used": "13887132",
+  "memory_usage_free": "10745120"
+}
+`
+
+func TestCollectSystemResourcesNxos(t *testing.T) {
+	tests := []struct {
+		name    string
+		outputs map[string]string
+		command string
+	}{
+		{"json", map[string]string{"show system resources | json": showSystemResourcesNxosJSON}, "show system resources | json"},
+		{"text", map[string]string{"show system resources": showSystemResourcesNxos}, "show system resources"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			metrics, commands := collect(t, rpc.NXOS, test.outputs)
+
+			if commands[test.command] != 1 {
+				t.Fatalf("expected %s to run once, ran %d times", test.command, commands[test.command])
+			}
+			expected := map[string]string{
+				"cpu_user_percent":     "3.1",
+				"cpu_idle_percent":     "95.7",
+				"load_fifteen_minutes": "0.52",
+				"memory_total":         "2.5223426048e+10",
+			}
+			for name, v := range expected {
+				if m := metrics[prefix+name]; m["value"] != v {
+					t.Errorf("unexpected %s %v", name, m)
+				}
+			}
+			if _, found := metrics[prefix+"cpu_five_seconds_percent"]; found {
+				t.Error("CPU utilization for five seconds not provided by NX-OS collected")
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package facts
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/lwlcom/cisco_exporter/rpc"
+)
+
+type versionJSON struct {
+	NxosVersion  string     `json:"nxos_ver_str"`
+	HostName     string     `json:"host_name"`
+	ChassisID    string     `json:"chassis_id"`
+	ProcBoardID  string     `json:"proc_board_id"`
+	NxosFileName string     `json:"nxos_file_name"`
+	IsanFileName string     `json:"isan_file_name"`
+	UptimeDays   rpc.Number `json:"kern_uptm_days"`
+	UptimeHours  rpc.Number `json:"kern_uptm_hrs"`
+	UptimeMins   rpc.Number `json:"kern_uptm_mins"`
+	UptimeSecs   rpc.Number `json:"kern_uptm_secs"`
+	ReloadReason string     `json:"rr_reason"`
+}
+
+type inventoryJSON struct {
+	Table struct {
+		Rows []struct {
+			ProductID string `json:"productid"`
+			SerialNum string `json:"serialnum"`
+		} `json:"ROW_inv"`
+	} `json:"TABLE_inv"`
+}
+
+type systemResourcesJSON struct {
+	LoadAvg1Min  rpc.Number `json:"load_avg_1min"`
+	LoadAvg5Min  rpc.Number `json:"load_avg_5min"`
+	LoadAvg15Min rpc.Number `json:"load_avg_15min"`
+	CPUUser      rpc.Number `json:"cpu_state_user"`
+	CPUKernel    rpc.Number `json:"cpu_state_kernel"`
+	CPUIdle      rpc.Number `json:"cpu_state_idle"`
+	MemoryTotal  rpc.Number `json:"memory_usage_total"`
+	MemoryUsed   rpc.Number `json:"memory_usage_used"`
+	MemoryFree   rpc.Number `json:"memory_usage_free"`
+}
+
+// versionFromJSON gets the version of the running OS from the JSON output of show version (NX-OS)
+func (c *factsCollector) versionFromJSON(ostype string, data *versionJSON) (VersionFact, error) {
+	if len(data.NxosVersion) == 0 {
+		return VersionFact{}, errors.New("Version string not found")
+	}
+
+	return VersionFact{Version: ostype + "-" + data.NxosVersion}, nil
+}
+
+// infoFromJSON gets the device informations from the JSON output of show version (NX-OS)
+func (c *factsCollector) infoFromJSON(data *versionJSON) (InfoFact, error) {
+	if len(data.HostName) == 0 {
+		return InfoFact{}, errors.New("Device name not found")
+	}
+
+	item := InfoFact{
+		Hostname:     data.HostName,
+		Model:        data.ChassisID,
+		Serial:       data.ProcBoardID,
+		Image:        data.NxosFileName,
+		Uptime:       float64(((data.UptimeDays*24+data.UptimeHours)*60+data.UptimeMins)*60 + data.UptimeSecs),
+		ReloadReason: data.ReloadReason,
+	}
+	if len(item.Image) == 0 {
+		item.Image = data.IsanFileName
+	}
+	item.Model = strings.TrimSuffix(strings.TrimSuffix(item.Model, " chassis"), " Chassis")
+
+	return item, nil
+}
+
+// getChassisJSON gets PID and serial number of the chassis from the JSON output of show inventory (NX-OS)
+func (c *factsCollector) getChassisJSON(ctx context.Context, client *rpc.Client) (ChassisFact, error) {
+	var data inventoryJSON
+	err := client.RunCommandJSON(ctx, "show inventory", &data)
+	if err != nil {
+		return ChassisFact{}, err
+	}
+	if len(data.Table.Rows) == 0 {
+		return ChassisFact{}, errors.New("Chassis not found")
+	}
+
+	return ChassisFact{
+		Model:  data.Table.Rows[0].ProductID,
+		Serial: data.Table.Rows[0].SerialNum,
+	}, nil
+}
+
+// getMemoryJSON gets the memory usage from the JSON output of show system resources (NX-OS), which is in kilobytes
+func (c *factsCollector) getMemoryJSON(ctx context.Context, client *rpc.Client, cmds *commandCache) ([]MemoryFact, error) {
+	data, err := cmds.systemResourcesJSON(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	if data.MemoryTotal == 0 {
+		return nil, errors.New("Memory usage not found")
+	}
+
+	return []MemoryFact{{
+		Type:  "System",
+		Total: float64(data.MemoryTotal) * 1024,
+		Used:  float64(data.MemoryUsed) * 1024,
+		Free:  float64(data.MemoryFree) * 1024,
+	}}, nil
+}
+
+// getCPUJSON gets load averages and CPU states from the JSON output of show system resources (NX-OS)
+func (c *factsCollector) getCPUJSON(ctx context.Context, client *rpc.Client, cmds *commandCache) (CPUFact, error) {
+	data, err := cmds.systemResourcesJSON(ctx, client)
+	if err != nil {
+		return CPUFact{}, err
+	}
+	if data.CPUUser+data.CPUKernel+data.CPUIdle == 0 {
+		return CPUFact{}, errors.New("CPU utilization not found")
+	}
+
+	item := newCPUFact()
+	item.User = float64(data.CPUUser)
+	item.Kernel = float64(data.CPUKernel)
+	item.Idle = float64(data.CPUIdle)
+	item.LoadOneMinute = float64(data.LoadAvg1Min)
+	item.LoadFiveMinutes = float64(data.LoadAvg5Min)
+	item.LoadFifteenMinutes = float64(data.LoadAvg15Min)
+	return item, nil
+}
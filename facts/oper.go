@@ -0,0 +1,132 @@
+package facts
+
+import (
+	"context"
+	"errors"
+	"regexp"
+
+	"github.com/lwlcom/cisco_exporter/rpc"
+)
+
+const (
+	cpuOperModule      = "Cisco-IOS-XE-process-cpu-oper"
+	memoryOperModule   = "Cisco-IOS-XE-memory-oper"
+	hardwareOperModule = "Cisco-IOS-XE-device-hardware-oper"
+	nxosDeviceModule   = "Cisco-NX-OS-device"
+)
+
+const (
+	cpuOperFilter      = `<cpu-usage xmlns="http://cisco.com/ns/yang/Cisco-IOS-XE-process-cpu-oper"><cpu-utilization/></cpu-usage>`
+	memoryOperFilter   = `<memory-statistics xmlns="http://cisco.com/ns/yang/Cisco-IOS-XE-memory-oper"/>`
+	hardwareOperFilter = `<device-hardware-data xmlns="http://cisco.com/ns/yang/Cisco-IOS-XE-device-hardware-oper"><device-hardware><device-system-data/></device-hardware></device-hardware-data>`
+)
+
+const (
+	cpuOperPath         = "Cisco-IOS-XE-process-cpu-oper:cpu-usage/cpu-utilization"
+	memoryOperPath      = "Cisco-IOS-XE-memory-oper:memory-statistics"
+	hardwareOperPath    = "Cisco-IOS-XE-device-hardware-oper:device-hardware-data/device-hardware/device-system-data"
+	nxosShowVersionPath = "Cisco-NX-OS-device:System/showversion-items"
+)
+
+var softwareVersionRegexp = regexp.MustCompile(`Version ([^\s,]+)`)
+
+type cpuOper struct {
+	CPUUtilization struct {
+		FiveSeconds     rpc.Number `xml:"five-seconds" json:"five-seconds"`
+		FiveSecondsIntr rpc.Number `xml:"five-seconds-intr" json:"five-seconds-intr"`
+		OneMinute       rpc.Number `xml:"one-minute" json:"one-minute"`
+		FiveMinutes     rpc.Number `xml:"five-minutes" json:"five-minutes"`
+	} `xml:"cpu-usage>cpu-utilization" json:"Cisco-IOS-XE-process-cpu-oper:cpu-utilization"`
+}
+
+type memoryOper struct {
+	MemoryStatistics struct {
+		Statistics []struct {
+			Name        string     `xml:"name" json:"name"`
+			TotalMemory rpc.Number `xml:"total-memory" json:"total-memory"`
+			UsedMemory  rpc.Number `xml:"used-memory" json:"used-memory"`
+			FreeMemory  rpc.Number `xml:"free-memory" json:"free-memory"`
+		} `xml:"memory-statistic" json:"memory-statistic"`
+	} `xml:"memory-statistics" json:"Cisco-IOS-XE-memory-oper:memory-statistics"`
+}
+
+type hardwareOper struct {
+	SystemData struct {
+		SoftwareVersion string `xml:"software-version" json:"software-version"`
+	} `xml:"device-hardware-data>device-hardware>device-system-data" json:"Cisco-IOS-XE-device-hardware-oper:device-system-data"`
+}
+
+type nxosShowVersion struct {
+	ShowVersion struct {
+		NxosVersion string `json:"nxosVersion"`
+	} `json:"Cisco-NX-OS-device:showversion-items"`
+}
+
+// getVersionOper gets the version of the running OS from the Cisco-IOS-XE-device-hardware-oper model
+func (c *factsCollector) getVersionOper(ctx context.Context, client *rpc.Client) (VersionFact, error) {
+	var data hardwareOper
+	err := client.GetOper(ctx, hardwareOperFilter, hardwareOperPath, &data)
+	if err != nil {
+		return VersionFact{}, err
+	}
+
+	matches := softwareVersionRegexp.FindStringSubmatch(data.SystemData.SoftwareVersion)
+	if matches == nil {
+		return VersionFact{}, errors.New("Version string not found")
+	}
+
+	return VersionFact{Version: client.OSType + "-" + matches[1]}, nil
+}
+
+// getVersionNxos gets the version of the running OS from the Cisco-NX-OS-device model, which is only available by RESTCONF
+func (c *factsCollector) getVersionNxos(ctx context.Context, client *rpc.Client) (VersionFact, error) {
+	var data nxosShowVersion
+	err := client.GetOper(ctx, "", nxosShowVersionPath, &data)
+	if err != nil {
+		return VersionFact{}, err
+	}
+
+	if len(data.ShowVersion.NxosVersion) == 0 {
+		return VersionFact{}, errors.New("Version string not found")
+	}
+
+	return VersionFact{Version: client.OSType + "-" + data.ShowVersion.NxosVersion}, nil
+}
+
+// getCPUOper gets the CPU utilization from the Cisco-IOS-XE-process-cpu-oper model
+func (c *factsCollector) getCPUOper(ctx context.Context, client *rpc.Client) (CPUFact, error) {
+	var data cpuOper
+	err := client.GetOper(ctx, cpuOperFilter, cpuOperPath, &data)
+	if err != nil {
+		return CPUFact{}, err
+	}
+
+	u := data.CPUUtilization
+	item := newCPUFact()
+	item.FiveSeconds = float64(u.FiveSeconds)
+	item.Interrupts = float64(u.FiveSecondsIntr)
+	item.OneMinute = float64(u.OneMinute)
+	item.FiveMinutes = float64(u.FiveMinutes)
+	return item, nil
+}
+
+// getMemoryOper gets the memory pools from the Cisco-IOS-XE-memory-oper model
+func (c *factsCollector) getMemoryOper(ctx context.Context, client *rpc.Client) ([]MemoryFact, error) {
+	var data memoryOper
+	err := client.GetOper(ctx, memoryOperFilter, memoryOperPath, &data)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]MemoryFact, len(data.MemoryStatistics.Statistics))
+	for i, s := range data.MemoryStatistics.Statistics {
+		items[i] = MemoryFact{
+			Type:  s.Name,
+			Total: float64(s.TotalMemory),
+			Used:  float64(s.UsedMemory),
+			Free:  float64(s.FreeMemory),
+		}
+	}
+
+	return items, nil
+}
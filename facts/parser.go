@@ -11,13 +11,15 @@ import (
 
 // ParseVersion parses cli output and tries to find the version number of the running OS
 func (c *factsCollector) ParseVersion(ostype string, output string) (VersionFact, error) {
-	if ostype != rpc.IOSXE && ostype != rpc.NXOS && ostype != rpc.IOS {
+	if ostype != rpc.IOSXE && ostype != rpc.IOSXR && ostype != rpc.NXOS && ostype != rpc.IOS && ostype != rpc.ASA {
 		return VersionFact{}, errors.New("'show version' is not implemented for " + ostype)
 	}
 	versionRegexp := make(map[string]*regexp.Regexp)
 	versionRegexp[rpc.IOSXE], _ = regexp.Compile(`^.*, Version (.+) -.*$`)
+	versionRegexp[rpc.IOSXR], _ = regexp.Compile(`^Cisco IOS XR Software, Version ([^\s\[]+).*$`)
 	versionRegexp[rpc.IOS], _ = regexp.Compile(`^.*, Version (.+),.*$`)
 	versionRegexp[rpc.NXOS], _ = regexp.Compile(`^\s+NXOS: version (.*)$`)
+	versionRegexp[rpc.ASA], _ = regexp.Compile(`^(?:Model\s+: )?Cisco (?:Adaptive Security Appliance Software|.*Threat Defense.*?) Version (\S+)`)
 
 	lines := strings.Split(output, "\n")
 	for _, line := range lines {
@@ -32,6 +34,15 @@ func (c *factsCollector) ParseVersion(ostype string, output string) (VersionFact
 
 // ParseMemory parses cli output and tries to find current memory usage
 func (c *factsCollector) ParseMemory(ostype string, output string) ([]MemoryFact, error) {
+	if ostype == rpc.IOSXR {
+		return c.parseMemoryXR(output)
+	}
+	if ostype == rpc.ASA {
+		return c.parseMemoryASA(output)
+	}
+	if ostype == rpc.NXOS {
+		return c.parseMemoryNxos(output)
+	}
 	if ostype != rpc.IOSXE && ostype != rpc.IOS {
 		return nil, errors.New("'show process memory' is not implemented for " + ostype)
 	}
@@ -57,6 +68,15 @@ func (c *factsCollector) ParseMemory(ostype string, output string) ([]MemoryFact
 
 // ParseCPU parses cli output and tries to find current CPU utilization
 func (c *factsCollector) ParseCPU(ostype string, output string) (CPUFact, error) {
+	if ostype == rpc.IOSXR {
+		return c.parseCPUXR(output)
+	}
+	if ostype == rpc.ASA {
+		return c.parseCPUASA(output)
+	}
+	if ostype == rpc.NXOS {
+		return c.parseCPUNxos(output)
+	}
 	if ostype != rpc.IOSXE && ostype != rpc.IOS {
 		return CPUFact{}, errors.New("'show process cpu' is not implemented for " + ostype)
 	}
@@ -68,12 +88,256 @@ func (c *factsCollector) ParseCPU(ostype string, output string) (CPUFact, error)
 		if matches == nil {
 			continue
 		}
-		return CPUFact{
-			FiveSeconds: util.Str2float64(matches[1]),
-			Interrupts:  util.Str2float64(matches[2]),
-			OneMinute:   util.Str2float64(matches[3]),
-			FiveMinutes: util.Str2float64(matches[4]),
-		}, nil
+		item := newCPUFact()
+		item.FiveSeconds = util.Str2float64(matches[1])
+		item.Interrupts = util.Str2float64(matches[2])
+		item.OneMinute = util.Str2float64(matches[3])
+		item.FiveMinutes = util.Str2float64(matches[4])
+		return item, nil
 	}
 	return CPUFact{}, errors.New("Version string not found")
 }
+
+// parseMemoryXR parses the output of show processes memory summary on IOS-XR (e.g. Physical Memory: 8192M total (6148M available))
+func (c *factsCollector) parseMemoryXR(output string) ([]MemoryFact, error) {
+	memoryRegexp := regexp.MustCompile(`^\s*(Physical|Application) Memory\s*: (\d+)M(?: total)? \((\d+)M available\)`)
+
+	items := []MemoryFact{}
+	for _, line := range strings.Split(output, "\n") {
+		matches := memoryRegexp.FindStringSubmatch(line)
+		if matches == nil {
+			continue
+		}
+		total := util.Str2float64(matches[2]) * 1024 * 1024
+		free := util.Str2float64(matches[3]) * 1024 * 1024
+		items = append(items, MemoryFact{
+			Type:  matches[1],
+			Total: total,
+			Used:  total - free,
+			Free:  free,
+		})
+	}
+	if len(items) == 0 {
+		return nil, errors.New("Memory summary not found")
+	}
+	return items, nil
+}
+
+// parseCPUXR parses the output of show processes cpu on IOS-XR, which has no five seconds and interrupt utilization
+func (c *factsCollector) parseCPUXR(output string) (CPUFact, error) {
+	cpuRegexp := regexp.MustCompile(`^\s*CPU utilization for one minute: (\d+)%; five minutes: (\d+)%`)
+
+	for _, line := range strings.Split(output, "\n") {
+		matches := cpuRegexp.FindStringSubmatch(line)
+		if matches == nil {
+			continue
+		}
+		item := newCPUFact()
+		item.OneMinute = util.Str2float64(matches[1])
+		item.FiveMinutes = util.Str2float64(matches[2])
+		return item, nil
+	}
+	return CPUFact{}, errors.New("CPU utilization not found")
+}
+
+// parseMemoryASA parses the output of show memory on ASA (e.g. Free memory: 6217768448 bytes (72%))
+func (c *factsCollector) parseMemoryASA(output string) ([]MemoryFact, error) {
+	memoryRegexp := regexp.MustCompile(`^(Free|Used|Total) memory:\s+(\d+) bytes`)
+
+	values := make(map[string]float64)
+	for _, line := range strings.Split(output, "\n") {
+		matches := memoryRegexp.FindStringSubmatch(line)
+		if matches == nil {
+			continue
+		}
+		values[matches[1]] = util.Str2float64(matches[2])
+	}
+	if _, found := values["Total"]; !found {
+		return nil, errors.New("Memory summary not found")
+	}
+	return []MemoryFact{{
+		Type:  "System",
+		Total: values["Total"],
+		Used:  values["Used"],
+		Free:  values["Free"],
+	}}, nil
+}
+
+// parseCPUASA parses the output of show cpu usage on ASA, which has no interrupt utilization
+func (c *factsCollector) parseCPUASA(output string) (CPUFact, error) {
+	cpuRegexp := regexp.MustCompile(`^CPU utilization for 5 seconds = (\d+)%; 1 minute: (\d+)%; 5 minutes: (\d+)%`)
+
+	for _, line := range strings.Split(output, "\n") {
+		matches := cpuRegexp.FindStringSubmatch(line)
+		if matches == nil {
+			continue
+		}
+		item := newCPUFact()
+		item.FiveSeconds = util.Str2float64(matches[1])
+		item.OneMinute = util.Str2float64(matches[2])
+		item.FiveMinutes = util.Str2float64(matches[3])
+		return item, nil
+	}
+	return CPUFact{}, errors.New("CPU utilization not found")
+}
+
+// ParseInfo parses the output of show version and tries to find hostname, model, serial, image, config register,
+// uptime and the last reload reason
+func (c *factsCollector) ParseInfo(ostype string, output string) (InfoFact, error) {
+	if ostype == rpc.NXOS {
+		return c.parseInfoNxos(output)
+	}
+	if ostype != rpc.IOSXE && ostype != rpc.IOS {
+		return InfoFact{}, errors.New("'show version' is not implemented for " + ostype)
+	}
+	uptimeRegexp := regexp.MustCompile(`^(\S+) uptime is (.+)$`)
+	modelRegexp := regexp.MustCompile(`^[Cc]isco (\S+) \(.*\) processor`)
+	serialRegexp := regexp.MustCompile(`^Processor board ID (\S+)`)
+	imageRegexp := regexp.MustCompile(`^System image file is "(.*)"`)
+	configRegisterRegexp := regexp.MustCompile(`^Configuration register is (\S+)`)
+	reloadReasonRegexp := regexp.MustCompile(`^Last reload reason: (.+?)\s*$`)
+	returnedRegexp := regexp.MustCompile(`^System returned to ROM by (.+?)(?: at .*)?\s*$`)
+
+	item := InfoFact{Uptime: -1}
+	for _, line := range strings.Split(output, "\n") {
+		if matches := uptimeRegexp.FindStringSubmatch(line); matches != nil {
+			item.Hostname = matches[1]
+			item.Uptime = parseUptime(matches[2])
+		} else if matches := modelRegexp.FindStringSubmatch(line); matches != nil {
+			item.Model = matches[1]
+		} else if matches := serialRegexp.FindStringSubmatch(line); matches != nil {
+			item.Serial = matches[1]
+		} else if matches := imageRegexp.FindStringSubmatch(line); matches != nil {
+			item.Image = matches[1]
+		} else if matches := configRegisterRegexp.FindStringSubmatch(line); matches != nil {
+			item.ConfigRegister = matches[1]
+		} else if matches := reloadReasonRegexp.FindStringSubmatch(line); matches != nil {
+			item.ReloadReason = matches[1]
+		} else if matches := returnedRegexp.FindStringSubmatch(line); matches != nil && item.ReloadReason == "" {
+			// older IOS versions have no last reload reason
+			item.ReloadReason = matches[1]
+		}
+	}
+	if item.Hostname == "" {
+		return InfoFact{}, errors.New("Uptime not found")
+	}
+	return item, nil
+}
+
+// parseInfoNxos parses the text output of show version on NX-OS, which has no config register
+func (c *factsCollector) parseInfoNxos(output string) (InfoFact, error) {
+	hostnameRegexp := regexp.MustCompile(`^\s+Device name: (\S+)`)
+	modelRegexp := regexp.MustCompile(`^\s+cisco (.+?) [Cc]hassis`)
+	serialRegexp := regexp.MustCompile(`^\s+Processor [Bb]oard ID (\S+)`)
+	imageRegexp := regexp.MustCompile(`^\s+(?:NXOS|system) image file is:\s+(\S+)`)
+	uptimeRegexp := regexp.MustCompile(`^Kernel uptime is (.+)$`)
+	reloadReasonRegexp := regexp.MustCompile(`^\s+Reason: (.+?)\s*$`)
+
+	item := InfoFact{Uptime: -1}
+	for _, line := range strings.Split(output, "\n") {
+		if matches := hostnameRegexp.FindStringSubmatch(line); matches != nil {
+			item.Hostname = matches[1]
+		} else if matches := modelRegexp.FindStringSubmatch(line); matches != nil {
+			item.Model = matches[1]
+		} else if matches := serialRegexp.FindStringSubmatch(line); matches != nil {
+			item.Serial = matches[1]
+		} else if matches := imageRegexp.FindStringSubmatch(line); matches != nil {
+			item.Image = matches[1]
+		} else if matches := uptimeRegexp.FindStringSubmatch(line); matches != nil {
+			item.Uptime = parseUptime(matches[1])
+		} else if matches := reloadReasonRegexp.FindStringSubmatch(line); matches != nil && item.ReloadReason == "" {
+			item.ReloadReason = matches[1]
+		}
+	}
+	if item.Hostname == "" {
+		return InfoFact{}, errors.New("Device name not found")
+	}
+	return item, nil
+}
+
+// ParseInventory parses the output of show inventory and returns the PID and serial number of the first entry,
+// which is the chassis
+func (c *factsCollector) ParseInventory(output string) (ChassisFact, error) {
+	inventoryRegexp := regexp.MustCompile(`PID: (\S*)\s*,\s*VID:.*,\s*SN: (\S+)`)
+
+	for _, line := range strings.Split(output, "\n") {
+		matches := inventoryRegexp.FindStringSubmatch(line)
+		if matches == nil {
+			continue
+		}
+		return ChassisFact{
+			Model:  matches[1],
+			Serial: matches[2],
+		}, nil
+	}
+	return ChassisFact{}, errors.New("Chassis not found")
+}
+
+// parseUptime converts an uptime (e.g. 1 year, 2 weeks, 3 days, 4 hours, 5 minutes or 3 day(s), 4 hour(s)) into seconds
+func parseUptime(uptime string) float64 {
+	unitRegexp := regexp.MustCompile(`(\d+) (year|week|day|hour|minute|second)`)
+	units := map[string]float64{
+		"year":   365 * 24 * 60 * 60,
+		"week":   7 * 24 * 60 * 60,
+		"day":    24 * 60 * 60,
+		"hour":   60 * 60,
+		"minute": 60,
+		"second": 1,
+	}
+
+	matches := unitRegexp.FindAllStringSubmatch(uptime, -1)
+	if matches == nil {
+		return -1
+	}
+	seconds := 0.0
+	for _, m := range matches {
+		seconds += util.Str2float64(m[1]) * units[m[2]]
+	}
+	return seconds
+}
+
+// parseMemoryNxos parses the output of show system resources on NX-OS (e.g. Memory usage: 24632252K total, 13887132K used, 10745120K free)
+func (c *factsCollector) parseMemoryNxos(output string) ([]MemoryFact, error) {
+	memoryRegexp := regexp.MustCompile(`^Memory usage:\s+(\d+)K total,\s+(\d+)K used,\s+(\d+)K free`)
+
+	for _, line := range strings.Split(output, "\n") {
+		matches := memoryRegexp.FindStringSubmatch(line)
+		if matches == nil {
+			continue
+		}
+		return []MemoryFact{{
+			Type:  "System",
+			Total: util.Str2float64(matches[1]) * 1024,
+			Used:  util.Str2float64(matches[2]) * 1024,
+			Free:  util.Str2float64(matches[3]) * 1024,
+		}}, nil
+	}
+	return nil, errors.New("Memory usage not found")
+}
+
+// parseCPUNxos parses the output of show system resources on NX-OS, which has load averages and CPU states
+// instead of the utilization for five seconds, one and five minutes
+func (c *factsCollector) parseCPUNxos(output string) (CPUFact, error) {
+	loadRegexp := regexp.MustCompile(`^Load average:\s+1 minute: ([\d.]+)\s+5 minutes: ([\d.]+)\s+15 minutes: ([\d.]+)`)
+	statesRegexp := regexp.MustCompile(`^CPU states\s+:\s+([\d.]+)% user,\s+([\d.]+)% kernel,\s+([\d.]+)% idle`)
+
+	item := newCPUFact()
+	found := false
+	for _, line := range strings.Split(output, "\n") {
+		if matches := loadRegexp.FindStringSubmatch(line); matches != nil {
+			item.LoadOneMinute = util.Str2float64(matches[1])
+			item.LoadFiveMinutes = util.Str2float64(matches[2])
+			item.LoadFifteenMinutes = util.Str2float64(matches[3])
+			found = true
+		} else if matches := statesRegexp.FindStringSubmatch(line); matches != nil {
+			item.User = util.Str2float64(matches[1])
+			item.Kernel = util.Str2float64(matches[2])
+			item.Idle = util.Str2float64(matches[3])
+			found = true
+		}
+	}
+	if !found {
+		return CPUFact{}, errors.New("CPU utilization not found")
+	}
+	return item, nil
+}
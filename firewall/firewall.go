@@ -0,0 +1,19 @@
+package firewall
+
+type CountFact struct {
+	InUse    float64
+	MostUsed float64
+}
+
+type FailoverFact struct {
+	Host  string
+	Role  string
+	State string
+}
+
+type VPNSessionFact struct {
+	Type       string
+	Active     float64
+	Cumulative float64
+	Peak       float64
+}
@@ -0,0 +1,159 @@
+package firewall
+
+import (
+	"context"
+	"log"
+
+	"github.com/lwlcom/cisco_exporter/rpc"
+
+	"github.com/lwlcom/cisco_exporter/collector"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const prefix string = "cisco_firewall_"
+
+type firewallCollector struct {
+	connectionsDesc           *prometheus.Desc
+	connectionsMaxDesc        *prometheus.Desc
+	xlatesDesc                *prometheus.Desc
+	xlatesMaxDesc             *prometheus.Desc
+	failoverActiveDesc        *prometheus.Desc
+	failoverStateDesc         *prometheus.Desc
+	vpnSessionsActiveDesc     *prometheus.Desc
+	vpnSessionsCumulativeDesc *prometheus.Desc
+	vpnSessionsPeakDesc       *prometheus.Desc
+	vpnSessionsCapacityDesc   *prometheus.Desc
+}
+
+// NewCollector creates a new collector. labels are the names of the labels identifying the target
+func NewCollector(labels []string) collector.RPCCollector {
+	c := &firewallCollector{}
+
+	l := labels
+	c.connectionsDesc = prometheus.NewDesc(prefix+"connections", "Number of connections in use", l, nil)
+	c.connectionsMaxDesc = prometheus.NewDesc(prefix+"connections_most_used", "Most connections in use at the same time", l, nil)
+	c.xlatesDesc = prometheus.NewDesc(prefix+"xlates", "Number of translations (xlates) in use", l, nil)
+	c.xlatesMaxDesc = prometheus.NewDesc(prefix+"xlates_most_used", "Most translations (xlates) in use at the same time", l, nil)
+
+	c.failoverActiveDesc = prometheus.NewDesc(prefix+"failover_active", "This host is the active unit (1 = Active)", l, nil)
+	c.failoverStateDesc = prometheus.NewDesc(prefix+"failover_state_info", "Failover state of a unit", append(l, "failover_peer", "failover_role", "failover_state"), nil)
+
+	c.vpnSessionsActiveDesc = prometheus.NewDesc(prefix+"vpn_sessions_active", "Number of active VPN sessions", append(l, "type"), nil)
+	c.vpnSessionsCumulativeDesc = prometheus.NewDesc(prefix+"vpn_sessions_cumulative", "Number of VPN sessions since the last reload", append(l, "type"), nil)
+	c.vpnSessionsPeakDesc = prometheus.NewDesc(prefix+"vpn_sessions_peak", "Peak number of concurrent VPN sessions", append(l, "type"), nil)
+	c.vpnSessionsCapacityDesc = prometheus.NewDesc(prefix+"vpn_sessions_capacity", "Total VPN session capacity of the device", l, nil)
+
+	return c
+}
+
+// Name returns the name of the collector
+func (*firewallCollector) Name() string {
+	return "Firewall"
+}
+
+// Describe describes the metrics
+func (c *firewallCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.connectionsDesc
+	ch <- c.connectionsMaxDesc
+	ch <- c.xlatesDesc
+	ch <- c.xlatesMaxDesc
+	ch <- c.failoverActiveDesc
+	ch <- c.failoverStateDesc
+	ch <- c.vpnSessionsActiveDesc
+	ch <- c.vpnSessionsCumulativeDesc
+	ch <- c.vpnSessionsPeakDesc
+	ch <- c.vpnSessionsCapacityDesc
+}
+
+// CollectCounts collects the number of connections and translations
+func (c *firewallCollector) CollectCounts(ctx context.Context, client *rpc.Client, ch chan<- prometheus.Metric, labelValues []string) error {
+	out, err := client.RunCommand(ctx, "show conn count")
+	if err != nil {
+		return err
+	}
+	conns, err := c.ParseCount(out)
+	if err != nil {
+		return err
+	}
+	ch <- prometheus.MustNewConstMetric(c.connectionsDesc, prometheus.GaugeValue, conns.InUse, labelValues...)
+	ch <- prometheus.MustNewConstMetric(c.connectionsMaxDesc, prometheus.GaugeValue, conns.MostUsed, labelValues...)
+
+	out, err = client.RunCommand(ctx, "show xlate count")
+	if err != nil {
+		return err
+	}
+	xlates, err := c.ParseCount(out)
+	if err != nil {
+		return err
+	}
+	ch <- prometheus.MustNewConstMetric(c.xlatesDesc, prometheus.GaugeValue, xlates.InUse, labelValues...)
+	ch <- prometheus.MustNewConstMetric(c.xlatesMaxDesc, prometheus.GaugeValue, xlates.MostUsed, labelValues...)
+	return nil
+}
+
+// CollectFailover collects the failover state of both units
+func (c *firewallCollector) CollectFailover(ctx context.Context, client *rpc.Client, ch chan<- prometheus.Metric, labelValues []string) error {
+	out, err := client.RunCommand(ctx, "show failover state")
+	if err != nil {
+		return err
+	}
+	items, err := c.ParseFailover(out)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		if item.Host == "this" {
+			active := 0
+			if item.State == "Active" {
+				active = 1
+			}
+			ch <- prometheus.MustNewConstMetric(c.failoverActiveDesc, prometheus.GaugeValue, float64(active), labelValues...)
+		}
+		l := append(labelValues, item.Host, item.Role, item.State)
+		ch <- prometheus.MustNewConstMetric(c.failoverStateDesc, prometheus.GaugeValue, 1, l...)
+	}
+	return nil
+}
+
+// CollectVPNSessions collects the number of VPN sessions per type
+func (c *firewallCollector) CollectVPNSessions(ctx context.Context, client *rpc.Client, ch chan<- prometheus.Metric, labelValues []string) error {
+	out, err := client.RunCommand(ctx, "show vpn-sessiondb summary")
+	if err != nil {
+		return err
+	}
+	items, capacity, err := c.ParseVPNSessions(out)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		l := append(labelValues, item.Type)
+		ch <- prometheus.MustNewConstMetric(c.vpnSessionsActiveDesc, prometheus.GaugeValue, item.Active, l...)
+		ch <- prometheus.MustNewConstMetric(c.vpnSessionsCumulativeDesc, prometheus.GaugeValue, item.Cumulative, l...)
+		ch <- prometheus.MustNewConstMetric(c.vpnSessionsPeakDesc, prometheus.GaugeValue, item.Peak, l...)
+	}
+	if capacity >= 0 {
+		ch <- prometheus.MustNewConstMetric(c.vpnSessionsCapacityDesc, prometheus.GaugeValue, capacity, labelValues...)
+	}
+	return nil
+}
+
+// Collect collects metrics from Cisco
+func (c *firewallCollector) Collect(ctx context.Context, client *rpc.Client, ch chan<- prometheus.Metric, labelValues []string) error {
+	if client.OSType != rpc.ASA {
+		return nil
+	}
+
+	err := c.CollectCounts(ctx, client, ch, labelValues)
+	if client.Debug && err != nil {
+		log.Printf("CollectCounts for %s: %s\n", labelValues[0], err.Error())
+	}
+	err = c.CollectFailover(ctx, client, ch, labelValues)
+	if client.Debug && err != nil {
+		log.Printf("CollectFailover for %s: %s\n", labelValues[0], err.Error())
+	}
+	err = c.CollectVPNSessions(ctx, client, ch, labelValues)
+	if client.Debug && err != nil {
+		log.Printf("CollectVPNSessions for %s: %s\n", labelValues[0], err.Error())
+	}
+	return nil
+}
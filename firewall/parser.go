@@ -0,0 +1,80 @@
+package firewall
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/lwlcom/cisco_exporter/util"
+)
+
+// ParseCount parses the output of show conn count and show xlate count (e.g. 54 in use, 1235 most used)
+func (c *firewallCollector) ParseCount(output string) (CountFact, error) {
+	countRegexp := regexp.MustCompile(`^(\d+) in use, (\d+) most used`)
+
+	for _, line := range strings.Split(output, "\n") {
+		matches := countRegexp.FindStringSubmatch(strings.TrimSpace(line))
+		if matches == nil {
+			continue
+		}
+		return CountFact{
+			InUse:    util.Str2float64(matches[1]),
+			MostUsed: util.Str2float64(matches[2]),
+		}, nil
+	}
+	return CountFact{}, errors.New("Count not found")
+}
+
+// ParseFailover parses the output of show failover state. Every host is followed by a line with its state
+func (c *firewallCollector) ParseFailover(output string) ([]FailoverFact, error) {
+	hostRegexp := regexp.MustCompile(`^(This|Other) host\s+-\s+(\w+)`)
+	columnRegexp := regexp.MustCompile(`\s{2,}`)
+
+	items := []FailoverFact{}
+	var current *FailoverFact
+	for _, line := range strings.Split(output, "\n") {
+		if matches := hostRegexp.FindStringSubmatch(line); matches != nil {
+			items = append(items, FailoverFact{
+				Host: strings.ToLower(matches[1]),
+				Role: matches[2],
+			})
+			current = &items[len(items)-1]
+			continue
+		}
+		if current == nil || len(strings.TrimSpace(line)) == 0 {
+			continue
+		}
+		current.State = columnRegexp.Split(strings.TrimSpace(line), -1)[0]
+		current = nil
+	}
+	if len(items) == 0 {
+		return nil, errors.New("Failover state not found")
+	}
+	return items, nil
+}
+
+// ParseVPNSessions parses the output of show vpn-sessiondb summary. Only the top level session types are used,
+// the indented protocols are part of them
+func (c *firewallCollector) ParseVPNSessions(output string) ([]VPNSessionFact, float64, error) {
+	sessionRegexp := regexp.MustCompile(`^(\S.*?)\s+:\s+(\d+) :\s+(\d+) :\s+(\d+)`)
+	capacityRegexp := regexp.MustCompile(`^Device Total VPN Capacity\s+:\s+(\d+)`)
+
+	items := []VPNSessionFact{}
+	capacity := -1.0
+	for _, line := range strings.Split(output, "\n") {
+		if matches := sessionRegexp.FindStringSubmatch(line); matches != nil {
+			items = append(items, VPNSessionFact{
+				Type:       matches[1],
+				Active:     util.Str2float64(matches[2]),
+				Cumulative: util.Str2float64(matches[3]),
+				Peak:       util.Str2float64(matches[4]),
+			})
+		} else if matches := capacityRegexp.FindStringSubmatch(line); matches != nil {
+			capacity = util.Str2float64(matches[1])
+		}
+	}
+	if len(items) == 0 && capacity < 0 {
+		return nil, -1, errors.New("VPN session summary not found")
+	}
+	return items, capacity, nil
+}
@@ -1,6 +1,8 @@
 package interfaces
 
 import (
+	"context"
+	"errors"
 	"log"
 
 	"github.com/lwlcom/cisco_exporter/rpc"
@@ -11,41 +13,38 @@ import (
 
 const prefix string = "cisco_interface_"
 
-var (
-	receiveBytesDesc       *prometheus.Desc
-	receiveErrorsDesc      *prometheus.Desc
-	receiveDropsDesc       *prometheus.Desc
-	receiveBroadcastDesc   *prometheus.Desc
-	receiveMulticastDesc   *prometheus.Desc
-	transmitBytesDesc      *prometheus.Desc
-	transmitErrorsDesc     *prometheus.Desc
-	transmitDropsDesc      *prometheus.Desc
-	adminStatusDesc        *prometheus.Desc
-	operStatusDesc         *prometheus.Desc
-	errorStatusDesc        *prometheus.Desc
-)
-
-func init() {
-	l := []string{"target", "name", "description", "mac", "speed"}
-	receiveBytesDesc = prometheus.NewDesc(prefix+"receive_bytes", "Received data in bytes", l, nil)
-	receiveErrorsDesc = prometheus.NewDesc(prefix+"receive_errors", "Number of errors caused by incoming packets", l, nil)
-	receiveDropsDesc = prometheus.NewDesc(prefix+"receive_drops", "Number of dropped incoming packets", l, nil)
-	receiveBroadcastDesc = prometheus.NewDesc(prefix+"receive_broadcast", "Received broadcast packets", l, nil)
-	receiveMulticastDesc = prometheus.NewDesc(prefix+"receive_multicast", "Received multicast packets", l, nil)
-	transmitBytesDesc = prometheus.NewDesc(prefix+"transmit_bytes", "Transmitted data in bytes", l, nil)
-	transmitErrorsDesc = prometheus.NewDesc(prefix+"transmit_errors", "Number of errors caused by outgoing packets", l, nil)
-	transmitDropsDesc = prometheus.NewDesc(prefix+"transmit_drops", "Number of dropped outgoing packets", l, nil)
-	adminStatusDesc = prometheus.NewDesc(prefix+"admin_up", "Admin operational status", l, nil)
-	operStatusDesc = prometheus.NewDesc(prefix+"up", "Interface operational status", l, nil)
-	errorStatusDesc = prometheus.NewDesc(prefix+"error_status", "Admin and operational status differ", l, nil)
-}
-
 type interfaceCollector struct {
+	receiveBytesDesc     *prometheus.Desc
+	receiveErrorsDesc    *prometheus.Desc
+	receiveDropsDesc     *prometheus.Desc
+	receiveBroadcastDesc *prometheus.Desc
+	receiveMulticastDesc *prometheus.Desc
+	transmitBytesDesc    *prometheus.Desc
+	transmitErrorsDesc   *prometheus.Desc
+	transmitDropsDesc    *prometheus.Desc
+	adminStatusDesc      *prometheus.Desc
+	operStatusDesc       *prometheus.Desc
+	errorStatusDesc      *prometheus.Desc
 }
 
-// NewCollector creates a new collector
-func NewCollector() collector.RPCCollector {
-	return &interfaceCollector{}
+// NewCollector creates a new collector. labels are the names of the labels identifying the target
+func NewCollector(labels []string) collector.RPCCollector {
+	c := &interfaceCollector{}
+
+	l := append(labels, "name", "description", "mac", "speed")
+	c.receiveBytesDesc = prometheus.NewDesc(prefix+"receive_bytes", "Received data in bytes", l, nil)
+	c.receiveErrorsDesc = prometheus.NewDesc(prefix+"receive_errors", "Number of errors caused by incoming packets", l, nil)
+	c.receiveDropsDesc = prometheus.NewDesc(prefix+"receive_drops", "Number of dropped incoming packets", l, nil)
+	c.receiveBroadcastDesc = prometheus.NewDesc(prefix+"receive_broadcast", "Received broadcast packets", l, nil)
+	c.receiveMulticastDesc = prometheus.NewDesc(prefix+"receive_multicast", "Received multicast packets", l, nil)
+	c.transmitBytesDesc = prometheus.NewDesc(prefix+"transmit_bytes", "Transmitted data in bytes", l, nil)
+	c.transmitErrorsDesc = prometheus.NewDesc(prefix+"transmit_errors", "Number of errors caused by outgoing packets", l, nil)
+	c.transmitDropsDesc = prometheus.NewDesc(prefix+"transmit_drops", "Number of dropped outgoing packets", l, nil)
+	c.adminStatusDesc = prometheus.NewDesc(prefix+"admin_up", "Admin operational status", l, nil)
+	c.operStatusDesc = prometheus.NewDesc(prefix+"up", "Interface operational status", l, nil)
+	c.errorStatusDesc = prometheus.NewDesc(prefix+"error_status", "Admin and operational status differ", l, nil)
+
+	return c
 }
 
 // Name returns the name of the collector
@@ -54,44 +53,108 @@ func (*interfaceCollector) Name() string {
 }
 
 // Describe describes the metrics
-func (*interfaceCollector) Describe(ch chan<- *prometheus.Desc) {
-	ch <- receiveBytesDesc
-	ch <- receiveErrorsDesc
-	ch <- receiveDropsDesc
-	ch <- receiveBroadcastDesc
-	ch <- receiveMulticastDesc
-	ch <- transmitBytesDesc
-	ch <- transmitDropsDesc
-	ch <- transmitErrorsDesc
-	ch <- adminStatusDesc
-	ch <- operStatusDesc
-	ch <- errorStatusDesc
+func (c *interfaceCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.receiveBytesDesc
+	ch <- c.receiveErrorsDesc
+	ch <- c.receiveDropsDesc
+	ch <- c.receiveBroadcastDesc
+	ch <- c.receiveMulticastDesc
+	ch <- c.transmitBytesDesc
+	ch <- c.transmitDropsDesc
+	ch <- c.transmitErrorsDesc
+	ch <- c.adminStatusDesc
+	ch <- c.operStatusDesc
+	ch <- c.errorStatusDesc
+}
+
+// SupportsOper checks if the interfaces can be collected from one of the YANG models
+func (c *interfaceCollector) SupportsOper(client *rpc.Client) bool {
+	return client.HasModule(operModule) || client.HasModule(openconfigModule)
 }
 
 // Collect collects metrics from Cisco
-func (c *interfaceCollector) Collect(client *rpc.Client, ch chan<- prometheus.Metric, labelValues []string) error {
-	out, err := client.RunCommand("show interface")
+func (c *interfaceCollector) Collect(ctx context.Context, client *rpc.Client, ch chan<- prometheus.Metric, labelValues []string) error {
+	var items []Interface
+	var err error
+	switch {
+	case client.HasModule(operModule):
+		items, err = c.getOper(ctx, client)
+	case client.HasModule(openconfigModule):
+		items, err = c.getOpenconfig(ctx, client)
+	case client.OSType == rpc.NXOS:
+		items, err = c.getJSON(ctx, client)
+		if errors.Is(err, rpc.ErrNoJSON) {
+			if client.Debug {
+				log.Printf("Parse interfaces JSON for %s: %s\n", labelValues[0], err.Error())
+			}
+			items, err = c.get(ctx, client, labelValues)
+		}
+	default:
+		items, err = c.get(ctx, client, labelValues)
+	}
 	if err != nil {
 		return err
 	}
+
+	for _, item := range items {
+		l := append(labelValues, item.Name, item.Description, item.MacAddress, item.Speed)
+
+		errorStatus := 0
+		if item.AdminStatus != item.OperStatus {
+			errorStatus = 1
+		}
+		adminStatus := 0
+		if item.AdminStatus == "up" {
+			adminStatus = 1
+		}
+		operStatus := 0
+		if item.OperStatus == "up" {
+			operStatus = 1
+		}
+		ch <- prometheus.MustNewConstMetric(c.receiveBytesDesc, prometheus.GaugeValue, item.InputBytes, l...)
+		ch <- prometheus.MustNewConstMetric(c.receiveErrorsDesc, prometheus.GaugeValue, item.InputErrors, l...)
+		ch <- prometheus.MustNewConstMetric(c.receiveDropsDesc, prometheus.GaugeValue, item.InputDrops, l...)
+		ch <- prometheus.MustNewConstMetric(c.transmitBytesDesc, prometheus.GaugeValue, item.OutputBytes, l...)
+		ch <- prometheus.MustNewConstMetric(c.transmitErrorsDesc, prometheus.GaugeValue, item.OutputErrors, l...)
+		ch <- prometheus.MustNewConstMetric(c.transmitDropsDesc, prometheus.GaugeValue, item.OutputDrops, l...)
+		ch <- prometheus.MustNewConstMetric(c.receiveBroadcastDesc, prometheus.GaugeValue, item.InputBroadcast, l...)
+		ch <- prometheus.MustNewConstMetric(c.receiveMulticastDesc, prometheus.GaugeValue, item.InputMulticast, l...)
+		ch <- prometheus.MustNewConstMetric(c.adminStatusDesc, prometheus.GaugeValue, float64(adminStatus), l...)
+		ch <- prometheus.MustNewConstMetric(c.operStatusDesc, prometheus.GaugeValue, float64(operStatus), l...)
+		ch <- prometheus.MustNewConstMetric(c.errorStatusDesc, prometheus.GaugeValue, float64(errorStatus), l...)
+	}
+
+	return nil
+}
+
+// get gets the interfaces from the CLI
+func (c *interfaceCollector) get(ctx context.Context, client *rpc.Client, labelValues []string) ([]Interface, error) {
+	cmd := "show interface"
+	if client.OSType == rpc.IOSXR {
+		cmd = "show interfaces"
+	}
+	out, err := client.RunCommand(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
 	items, err := c.Parse(client.OSType, out)
 	if err != nil {
 		if client.Debug {
 			log.Printf("Parse interfaces for %s: %s\n", labelValues[0], err.Error())
 		}
-		return nil
+		return nil, nil
 	}
 	if client.OSType == rpc.IOSXE {
-		out, err := client.RunCommand("show vlans")
+		out, err := client.RunCommand(ctx, "show vlans")
 		if err != nil {
-			return err
+			return nil, err
 		}
 		vlans, err := c.ParseVlans(client.OSType, out)
 		if err != nil {
 			if client.Debug {
 				log.Printf("Parse vlans for %s: %s\n", labelValues[0], err.Error())
 			}
-			return nil
+			return nil, nil
 		}
 		for _, vlan := range vlans {
 			for i, item := range items {
@@ -104,33 +167,5 @@ func (c *interfaceCollector) Collect(client *rpc.Client, ch chan<- prometheus.Me
 		}
 	}
 
-	for _, item := range items {
-		l := append(labelValues, item.Name, item.Description, item.MacAddress, item.Speed)
-
-		errorStatus := 0
-		if item.AdminStatus != item.OperStatus {
-			errorStatus = 1
-		}
-		adminStatus := 0
-		if item.AdminStatus == "up" {
-			adminStatus = 1
-		}
-		operStatus := 0
-		if item.OperStatus == "up" {
-			operStatus = 1
-		}
-		ch <- prometheus.MustNewConstMetric(receiveBytesDesc, prometheus.GaugeValue, item.InputBytes, l...)
-		ch <- prometheus.MustNewConstMetric(receiveErrorsDesc, prometheus.GaugeValue, item.InputErrors, l...)
-		ch <- prometheus.MustNewConstMetric(receiveDropsDesc, prometheus.GaugeValue, item.InputDrops, l...)
-		ch <- prometheus.MustNewConstMetric(transmitBytesDesc, prometheus.GaugeValue, item.OutputBytes, l...)
-		ch <- prometheus.MustNewConstMetric(transmitErrorsDesc, prometheus.GaugeValue, item.OutputErrors, l...)
-		ch <- prometheus.MustNewConstMetric(transmitDropsDesc, prometheus.GaugeValue, item.OutputDrops, l...)
-		ch <- prometheus.MustNewConstMetric(receiveBroadcastDesc, prometheus.GaugeValue, item.InputBroadcast, l...)
-		ch <- prometheus.MustNewConstMetric(receiveMulticastDesc, prometheus.GaugeValue, item.InputMulticast, l...)
-		ch <- prometheus.MustNewConstMetric(adminStatusDesc, prometheus.GaugeValue, float64(adminStatus), l...)
-		ch <- prometheus.MustNewConstMetric(operStatusDesc, prometheus.GaugeValue, float64(operStatus), l...)
-		ch <- prometheus.MustNewConstMetric(errorStatusDesc, prometheus.GaugeValue, float64(errorStatus), l...)
-	}
-
-	return nil
+	return items, nil
 }
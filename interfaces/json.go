@@ -0,0 +1,83 @@
+package interfaces
+
+import (
+	"context"
+	"strings"
+
+	"github.com/lwlcom/cisco_exporter/rpc"
+)
+
+type interfacesJSON struct {
+	Table struct {
+		Rows []interfaceJSON `json:"ROW_interface"`
+	} `json:"TABLE_interface"`
+}
+
+type interfaceJSON struct {
+	Name        string     `json:"interface"`
+	State       string     `json:"state"`
+	AdminState  string     `json:"admin_state"`
+	Description string     `json:"desc"`
+	HwAddr      string     `json:"eth_hw_addr"`
+	Speed       string     `json:"eth_speed"`
+	InBytes     rpc.Number `json:"eth_inbytes"`
+	InMcast     rpc.Number `json:"eth_inmcast"`
+	InBcast     rpc.Number `json:"eth_inbcast"`
+	InErr       rpc.Number `json:"eth_inerr"`
+	InDiscard   rpc.Number `json:"eth_indiscard"`
+	OutBytes    rpc.Number `json:"eth_outbytes"`
+	OutErr      rpc.Number `json:"eth_outerr"`
+	OutDiscard  rpc.Number `json:"eth_outdiscard"`
+
+	// mgmt0
+	MgmtInBytes  rpc.Number `json:"vdc_lvl_in_bytes"`
+	MgmtInMcast  rpc.Number `json:"vdc_lvl_in_mcast"`
+	MgmtInBcast  rpc.Number `json:"vdc_lvl_in_bcast"`
+	MgmtOutBytes rpc.Number `json:"vdc_lvl_out_bytes"`
+
+	// SVIs
+	SviAdminState string `json:"svi_admin_state"`
+	SviLineProto  string `json:"svi_line_proto"`
+	SviDesc       string `json:"svi_desc"`
+	SviMac        string `json:"svi_mac"`
+}
+
+// getJSON gets the interfaces from the JSON output of show interface (NX-OS)
+func (c *interfaceCollector) getJSON(ctx context.Context, client *rpc.Client) ([]Interface, error) {
+	var data interfacesJSON
+	err := client.RunCommandJSON(ctx, "show interface", &data)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]Interface, len(data.Table.Rows))
+	for i, r := range data.Table.Rows {
+		item := Interface{
+			Name:           r.Name,
+			Description:    r.Description,
+			MacAddress:     r.HwAddr,
+			AdminStatus:    operStatus(r.AdminState == "up"),
+			OperStatus:     operStatus(r.State == "up"),
+			InputBytes:     float64(r.InBytes + r.MgmtInBytes),
+			InputMulticast: float64(r.InMcast + r.MgmtInMcast),
+			InputBroadcast: float64(r.InBcast + r.MgmtInBcast),
+			InputErrors:    float64(r.InErr),
+			InputDrops:     float64(r.InDiscard),
+			OutputBytes:    float64(r.OutBytes + r.MgmtOutBytes),
+			OutputErrors:   float64(r.OutErr),
+			OutputDrops:    float64(r.OutDiscard),
+		}
+		if strings.HasSuffix(r.Speed, "/s") {
+			item.Speed = r.Speed
+		}
+		if len(r.SviAdminState) > 0 {
+			item.AdminStatus = operStatus(r.SviAdminState == "up")
+			item.OperStatus = operStatus(r.SviLineProto == "up")
+			item.Description = r.SviDesc
+			item.MacAddress = r.SviMac
+		}
+		items[i] = item
+	}
+
+	return items, nil
+}
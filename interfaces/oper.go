@@ -0,0 +1,201 @@
+package interfaces
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lwlcom/cisco_exporter/rpc"
+)
+
+const (
+	operModule       = "Cisco-IOS-XE-interfaces-oper"
+	openconfigModule = "openconfig-interfaces"
+)
+
+const (
+	operFilter       = `<interfaces xmlns="http://cisco.com/ns/yang/Cisco-IOS-XE-interfaces-oper"/>`
+	openconfigFilter = `<interfaces xmlns="http://openconfig.net/yang/interfaces"/>`
+)
+
+const (
+	operPath       = "Cisco-IOS-XE-interfaces-oper:interfaces"
+	openconfigPath = "openconfig-interfaces:interfaces"
+)
+
+type interfacesOper struct {
+	Interfaces struct {
+		Interface []interfaceOper `xml:"interface" json:"interface"`
+	} `xml:"interfaces" json:"Cisco-IOS-XE-interfaces-oper:interfaces"`
+}
+
+type interfaceOper struct {
+	Name        string     `xml:"name" json:"name"`
+	Description string     `xml:"description" json:"description"`
+	AdminStatus string     `xml:"admin-status" json:"admin-status"`
+	OperStatus  string     `xml:"oper-status" json:"oper-status"`
+	PhysAddress string     `xml:"phys-address" json:"phys-address"`
+	Speed       rpc.Number `xml:"speed" json:"speed"`
+	Statistics  struct {
+		InOctets        rpc.Number `xml:"in-octets" json:"in-octets"`
+		InBroadcastPkts rpc.Number `xml:"in-broadcast-pkts" json:"in-broadcast-pkts"`
+		InMulticastPkts rpc.Number `xml:"in-multicast-pkts" json:"in-multicast-pkts"`
+		InDiscards      rpc.Number `xml:"in-discards" json:"in-discards"`
+		InErrors        rpc.Number `xml:"in-errors" json:"in-errors"`
+		OutOctets       rpc.Number `xml:"out-octets" json:"out-octets"`
+		OutDiscards     rpc.Number `xml:"out-discards" json:"out-discards"`
+		OutErrors       rpc.Number `xml:"out-errors" json:"out-errors"`
+	} `xml:"statistics" json:"statistics"`
+}
+
+type interfacesOpenconfig struct {
+	Interfaces struct {
+		Interface []interfaceOpenconfig `xml:"interface" json:"interface"`
+	} `xml:"interfaces" json:"openconfig-interfaces:interfaces"`
+}
+
+type interfaceOpenconfig struct {
+	Name  string `xml:"name" json:"name"`
+	State struct {
+		Description string `xml:"description" json:"description"`
+		AdminStatus string `xml:"admin-status" json:"admin-status"`
+		OperStatus  string `xml:"oper-status" json:"oper-status"`
+		Counters    struct {
+			InOctets        rpc.Number `xml:"in-octets" json:"in-octets"`
+			InBroadcastPkts rpc.Number `xml:"in-broadcast-pkts" json:"in-broadcast-pkts"`
+			InMulticastPkts rpc.Number `xml:"in-multicast-pkts" json:"in-multicast-pkts"`
+			InDiscards      rpc.Number `xml:"in-discards" json:"in-discards"`
+			InErrors        rpc.Number `xml:"in-errors" json:"in-errors"`
+			OutOctets       rpc.Number `xml:"out-octets" json:"out-octets"`
+			OutDiscards     rpc.Number `xml:"out-discards" json:"out-discards"`
+			OutErrors       rpc.Number `xml:"out-errors" json:"out-errors"`
+		} `xml:"counters" json:"counters"`
+	} `xml:"state" json:"state"`
+	Ethernet struct {
+		State struct {
+			MacAddress string `xml:"mac-address" json:"mac-address"`
+			PortSpeed  string `xml:"port-speed" json:"port-speed"`
+		} `xml:"state" json:"state"`
+	} `xml:"ethernet" json:"openconfig-if-ethernet:ethernet"`
+}
+
+// getOper gets the interfaces from the Cisco-IOS-XE-interfaces-oper model
+func (c *interfaceCollector) getOper(ctx context.Context, client *rpc.Client) ([]Interface, error) {
+	var data interfacesOper
+	err := client.GetOper(ctx, operFilter, operPath, &data)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]Interface, len(data.Interfaces.Interface))
+	for i, o := range data.Interfaces.Interface {
+		items[i] = Interface{
+			Name:           o.Name,
+			Description:    o.Description,
+			MacAddress:     ciscoMacAddress(o.PhysAddress),
+			AdminStatus:    operStatus(o.AdminStatus == "if-state-up"),
+			OperStatus:     operStatus(o.OperStatus == "if-oper-state-ready"),
+			InputBytes:     float64(o.Statistics.InOctets),
+			InputBroadcast: float64(o.Statistics.InBroadcastPkts),
+			InputMulticast: float64(o.Statistics.InMulticastPkts),
+			InputDrops:     float64(o.Statistics.InDiscards),
+			InputErrors:    float64(o.Statistics.InErrors),
+			OutputBytes:    float64(o.Statistics.OutOctets),
+			OutputDrops:    float64(o.Statistics.OutDiscards),
+			OutputErrors:   float64(o.Statistics.OutErrors),
+			Speed:          speedString(uint64(o.Speed)),
+		}
+	}
+
+	return items, nil
+}
+
+// getOpenconfig gets the interfaces from the openconfig-interfaces model (e.g. on NX-OS)
+func (c *interfaceCollector) getOpenconfig(ctx context.Context, client *rpc.Client) ([]Interface, error) {
+	var data interfacesOpenconfig
+	err := client.GetOper(ctx, openconfigFilter, openconfigPath, &data)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]Interface, len(data.Interfaces.Interface))
+	for i, o := range data.Interfaces.Interface {
+		s := o.State
+		items[i] = Interface{
+			Name:           o.Name,
+			Description:    s.Description,
+			MacAddress:     ciscoMacAddress(o.Ethernet.State.MacAddress),
+			AdminStatus:    operStatus(s.AdminStatus == "UP"),
+			OperStatus:     operStatus(s.OperStatus == "UP"),
+			InputBytes:     float64(s.Counters.InOctets),
+			InputBroadcast: float64(s.Counters.InBroadcastPkts),
+			InputMulticast: float64(s.Counters.InMulticastPkts),
+			InputDrops:     float64(s.Counters.InDiscards),
+			InputErrors:    float64(s.Counters.InErrors),
+			OutputBytes:    float64(s.Counters.OutOctets),
+			OutputDrops:    float64(s.Counters.OutDiscards),
+			OutputErrors:   float64(s.Counters.OutErrors),
+			Speed:          portSpeedString(o.Ethernet.State.PortSpeed),
+		}
+	}
+
+	return items, nil
+}
+
+func operStatus(up bool) string {
+	if up {
+		return "up"
+	}
+
+	return "down"
+}
+
+// ciscoMacAddress formats a MAC address like the CLI does (0000.1111.2222)
+func ciscoMacAddress(mac string) string {
+	hex := strings.Replace(mac, ":", "", -1)
+	if len(hex) != 12 {
+		return mac
+	}
+
+	return hex[0:4] + "." + hex[4:8] + "." + hex[8:12]
+}
+
+// speedString formats a speed in bits per second like the CLI parser does: in Gb/s from 10 Gb/s on and in Mb/s below,
+// as IOS, IOS-XE and NX-OS print it (e.g. 1000 Mb/s and 10 Gb/s)
+func speedString(speed uint64) string {
+	switch {
+	case speed == 0:
+		return ""
+	case speed >= 10000000000 && speed%1000000000 == 0:
+		return fmt.Sprintf("%d Gb/s", speed/1000000000)
+	case speed%1000000 == 0:
+		return fmt.Sprintf("%d Mb/s", speed/1000000)
+	default:
+		return fmt.Sprintf("%d Kb/s", speed/1000)
+	}
+}
+
+// portSpeedString formats an openconfig port speed (e.g. openconfig-if-ethernet:SPEED_10GB) like speedString
+func portSpeedString(speed string) string {
+	if i := strings.LastIndex(speed, ":"); i >= 0 {
+		speed = speed[i+1:]
+	}
+	speed = strings.TrimPrefix(speed, "SPEED_")
+
+	var unit uint64
+	switch {
+	case strings.HasSuffix(speed, "GB"):
+		unit = 1000000000
+	case strings.HasSuffix(speed, "MB"):
+		unit = 1000000
+	default:
+		return ""
+	}
+	n, err := strconv.ParseUint(speed[:len(speed)-2], 10, 64)
+	if err != nil {
+		return ""
+	}
+
+	return speedString(n * unit)
+}
@@ -0,0 +1,61 @@
+package interfaces
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/lwlcom/cisco_exporter/rpc"
+)
+
+const showInterfaceIOSXE = `GigabitEthernet1/0/1 is up, line protocol is up (connected)
+  Hardware is Gigabit Ethernet, address is 001e.bd4c.2a01 (bia 001e.bd4c.2a01)
+  Description: uplink
+  %s
+`
+
+const showInterfaceNxos = `Ethernet1/1 is up
+admin state is up, Dedicated Interface
+  Hardware: 100/1000/10000/25000 Ethernet, address: 00de.fb12.3456 (bia 00de.fb12.3456)
+  %s
+`
+
+func TestSpeedMatchesCLI(t *testing.T) {
+	c := &interfaceCollector{}
+
+	tests := []struct {
+		cli   string
+		speed uint64
+	}{
+		{"Full-duplex, 1000Mb/s, media type is 10/100/1000BaseTX", 1000000000},
+		{"Full Duplex, 1000Mbps, link type is auto, media type is 10/100/1000BaseTX", 1000000000},
+		{"Full-duplex, 100Mb/s, media type is 10/100/1000BaseTX", 100000000},
+		{"Full-duplex, 10Gb/s, link type is force-up, media type is SFP-10GBase-SR", 10000000000},
+	}
+	for _, test := range tests {
+		items, err := c.Parse(rpc.IOSXE, fmt.Sprintf(showInterfaceIOSXE, test.cli))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(items[0].Speed) == 0 || items[0].Speed != speedString(test.speed) {
+			t.Errorf("speed of %q from model is %q, from CLI %q", test.cli, speedString(test.speed), items[0].Speed)
+		}
+	}
+
+	openconfig := []struct {
+		cli   string
+		speed string
+	}{
+		{"full-duplex, 1000 Mb/s, media type is 1G", "openconfig-if-ethernet:SPEED_1GB"},
+		{"full-duplex, 10 Gb/s, media type is 10G", "openconfig-if-ethernet:SPEED_10GB"},
+		{"full-duplex, 100 Gb/s, media type is 100G", "openconfig-if-ethernet:SPEED_100GB"},
+	}
+	for _, test := range openconfig {
+		items, err := c.Parse(rpc.NXOS, fmt.Sprintf(showInterfaceNxos, test.cli))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(items[0].Speed) == 0 || items[0].Speed != portSpeedString(test.speed) {
+			t.Errorf("speed of %q from model is %q, from CLI %q", test.cli, portSpeedString(test.speed), items[0].Speed)
+		}
+	}
+}
@@ -11,6 +11,12 @@ import (
 
 // Parse parses cli output and tries to find interfaces with related stats
 func (c *interfaceCollector) Parse(ostype string, output string) ([]Interface, error) {
+	if ostype == rpc.IOSXR {
+		return c.parseXR(output), nil
+	}
+	if ostype == rpc.ASA {
+		return c.parseASA(output), nil
+	}
 	if ostype != rpc.IOSXE && ostype != rpc.NXOS && ostype != rpc.IOS {
 		return nil, errors.New("'show interface' is not implemented for " + ostype)
 	}
@@ -30,7 +36,7 @@ func (c *interfaceCollector) Parse(ostype string, output string) ([]Interface, e
 	outputBytesRegexp := regexp.MustCompile(`^\s+\d+ (?:packets output,|output packets)\s+(\d+) bytes.*$`)
 	inputErrorsRegexp := regexp.MustCompile(`^\s+(\d+) input error(?:s,)? .*$`)
 	outputErrorsRegexp := regexp.MustCompile(`^\s+(\d+) output error(?:s,)? .*$`)
-	speedRegexp := regexp.MustCompile(`^\s+(.*)-duplex,\s(\d+) ((\wb)/s).*$`)
+	speedRegexp := regexp.MustCompile(`^\s+.*[- ][Dd]uplex,\s(\d+) ?(\w)b(?:/s|ps)`) // 10 Gb/s (NX OS), 1000Mb/s or 1000Mbps (IOS XE)
 
 	isRx := true
 	current := Interface{}
@@ -83,7 +89,7 @@ func (c *interfaceCollector) Parse(ostype string, output string) ([]Interface, e
 		} else if matches := outputErrorsRegexp.FindStringSubmatch(line); matches != nil {
 			current.OutputErrors = util.Str2float64(matches[1])
 		} else if matches := speedRegexp.FindStringSubmatch(line); matches != nil {
-			current.Speed = matches[2] + " " + matches[3]
+			current.Speed = matches[1] + " " + matches[2] + "b/s"
 		} else if matches := txNXOS.FindStringSubmatch(line); matches != nil {
 			isRx = false
 		} else if matches := multiBroadNXOS.FindStringSubmatch(line); matches != nil {
@@ -101,6 +107,125 @@ func (c *interfaceCollector) Parse(ostype string, output string) ([]Interface, e
 	return append(items, current), nil
 }
 
+// parseXR parses the output of show interfaces on IOS-XR
+func (c *interfaceCollector) parseXR(output string) []Interface {
+	items := []Interface{}
+	deviceNameRegexp := regexp.MustCompile(`^(\S+) is (administratively )?(up|down)[^,]*, line protocol is.*$`)
+	macRegexp := regexp.MustCompile(`^\s+Hardware is .+, address is (\S+)`)
+	descRegexp := regexp.MustCompile(`^\s+Description: (.*)$`)
+	inputRegexp := regexp.MustCompile(`^\s+\d+ packets input, (\d+) bytes(?:, (\d+) total input drops)?`)
+	outputRegexp := regexp.MustCompile(`^\s+\d+ packets output, (\d+) bytes(?:, (\d+) total output drops)?`)
+	receivedRegexp := regexp.MustCompile(`^\s+Received (\d+) broadcast packets, (\d+) multicast packets`)
+	inputErrorsRegexp := regexp.MustCompile(`^\s+(\d+) input errors,`)
+	outputErrorsRegexp := regexp.MustCompile(`^\s+(\d+) output errors,`)
+	speedRegexp := regexp.MustCompile(`^\s+\S+-duplex, (\d+)(\w)b/s`)
+
+	var current *Interface
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, " ")
+		if matches := deviceNameRegexp.FindStringSubmatch(line); matches != nil {
+			items = append(items, Interface{
+				Name:        matches[1],
+				AdminStatus: "up",
+				OperStatus:  matches[3],
+			})
+			current = &items[len(items)-1]
+			if matches[2] != "" {
+				current.AdminStatus = "down"
+			}
+			continue
+		}
+		if current == nil {
+			continue
+		}
+
+		if matches := descRegexp.FindStringSubmatch(line); matches != nil {
+			current.Description = matches[1]
+		} else if matches := macRegexp.FindStringSubmatch(line); matches != nil {
+			current.MacAddress = matches[1]
+		} else if matches := inputRegexp.FindStringSubmatch(line); matches != nil {
+			current.InputBytes = util.Str2float64(matches[1])
+			if matches[2] != "" {
+				current.InputDrops = util.Str2float64(matches[2])
+			}
+		} else if matches := outputRegexp.FindStringSubmatch(line); matches != nil {
+			current.OutputBytes = util.Str2float64(matches[1])
+			if matches[2] != "" {
+				current.OutputDrops = util.Str2float64(matches[2])
+			}
+		} else if matches := receivedRegexp.FindStringSubmatch(line); matches != nil {
+			current.InputBroadcast = util.Str2float64(matches[1])
+			current.InputMulticast = util.Str2float64(matches[2])
+		} else if matches := inputErrorsRegexp.FindStringSubmatch(line); matches != nil {
+			current.InputErrors = util.Str2float64(matches[1])
+		} else if matches := outputErrorsRegexp.FindStringSubmatch(line); matches != nil {
+			current.OutputErrors = util.Str2float64(matches[1])
+		} else if matches := speedRegexp.FindStringSubmatch(line); matches != nil {
+			current.Speed = matches[1] + " " + matches[2] + "b/s"
+		}
+	}
+
+	return items
+}
+
+// parseASA parses the output of show interface on ASA. Only the hardware counters are used,
+// the traffic statistics of the named interfaces are skipped
+func (c *interfaceCollector) parseASA(output string) []Interface {
+	items := []Interface{}
+	deviceNameRegexp := regexp.MustCompile(`^Interface (\S+) "[^"]*", is (administratively )?(up|down), line protocol is`)
+	speedRegexp := regexp.MustCompile(`^\s+Hardware is .*, BW (\d+) (\w)bps`)
+	macRegexp := regexp.MustCompile(`^\s+MAC address (\S+),`)
+	descRegexp := regexp.MustCompile(`^\s+Description: (.*)$`)
+	inputRegexp := regexp.MustCompile(`^\s+\d+ packets input, (\d+) bytes`)
+	outputRegexp := regexp.MustCompile(`^\s+\d+ packets output, (\d+) bytes`)
+	broadcastRegexp := regexp.MustCompile(`^\s+Received (\d+) broadcasts`)
+	inputErrorsRegexp := regexp.MustCompile(`^\s+(\d+) input errors,`)
+	outputErrorsRegexp := regexp.MustCompile(`^\s+(\d+) output errors,`)
+
+	var current *Interface
+	for _, line := range strings.Split(output, "\n") {
+		if matches := deviceNameRegexp.FindStringSubmatch(line); matches != nil {
+			items = append(items, Interface{
+				Name:        matches[1],
+				AdminStatus: "up",
+				OperStatus:  matches[3],
+			})
+			current = &items[len(items)-1]
+			if matches[2] != "" {
+				current.AdminStatus = "down"
+			}
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		if strings.HasPrefix(strings.TrimSpace(line), "Traffic Statistics for") {
+			current = nil
+			continue
+		}
+
+		if matches := descRegexp.FindStringSubmatch(line); matches != nil {
+			current.Description = matches[1]
+		} else if matches := speedRegexp.FindStringSubmatch(line); matches != nil {
+			current.Speed = matches[1] + " " + matches[2] + "b/s"
+		} else if matches := macRegexp.FindStringSubmatch(line); matches != nil {
+			current.MacAddress = matches[1]
+		} else if matches := inputRegexp.FindStringSubmatch(line); matches != nil {
+			current.InputBytes = util.Str2float64(matches[1])
+		} else if matches := outputRegexp.FindStringSubmatch(line); matches != nil {
+			current.OutputBytes = util.Str2float64(matches[1])
+		} else if matches := broadcastRegexp.FindStringSubmatch(line); matches != nil {
+			current.InputBroadcast = util.Str2float64(matches[1])
+		} else if matches := inputErrorsRegexp.FindStringSubmatch(line); matches != nil {
+			current.InputErrors = util.Str2float64(matches[1])
+		} else if matches := outputErrorsRegexp.FindStringSubmatch(line); matches != nil {
+			current.OutputErrors = util.Str2float64(matches[1])
+		}
+	}
+
+	return items
+}
+
 // ParseVlans parses cli output and tries to find vlans with related traffic stats
 func (c *interfaceCollector) ParseVlans(ostype string, output string) ([]Interface, error) {
 	if ostype != rpc.IOSXE {
@@ -2,14 +2,20 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"flag"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"os"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/lwlcom/cisco_exporter/config"
 	"github.com/lwlcom/cisco_exporter/connector"
+	"github.com/lwlcom/cisco_exporter/discovery"
+	"github.com/lwlcom/cisco_exporter/secrets"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	log "github.com/sirupsen/logrus"
@@ -21,22 +27,40 @@ var (
 	showVersion        = flag.Bool("version", false, "Print version information.")
 	listenAddress      = flag.String("web.listen-address", ":9362", "Address on which to expose metrics and web interface.")
 	metricsPath        = flag.String("web.telemetry-path", "/metrics", "Path under which to expose metrics.")
+	timeoutOffset      = flag.Duration("web.timeout-offset", 500*time.Millisecond, "Offset to subtract from the scrape timeout sent by Prometheus")
+	probePath          = flag.String("web.probe-path", "/probe", "Path under which to expose metrics of a single target.")
 	sshHosts           = flag.String("ssh.targets", "", "SSH Hosts to scrape")
 	sshUsername        = flag.String("ssh.user", "cisco_exporter", "Username to use for SSH connection")
 	sshPassword        = flag.String("ssh.password", "", "Password to use for SSH connection")
+	sshPasswordFile    = flag.String("ssh.password-file", "", "File containing the password to use for SSH connection")
 	sshKeyFile         = flag.String("ssh.keyfile", "", "Key file to use for SSH connection")
 	sshTimeout         = flag.Int("ssh.timeout", 5, "Timeout to use for SSH connection")
+	sshKnownHostsFile  = flag.String("ssh.known-hosts-file", "", "known_hosts file to verify host keys against")
+	sshTrustOnFirstUse = flag.Bool("ssh.trust-on-first-use", false, "Add host keys of unknown hosts to the known_hosts file")
 	sshBatchSize       = flag.Int("ssh.batch-size", 10000, "The SSH response batch size")
+	scrapeInterval     = flag.Duration("scrape.interval", 0, "Interval to scrape devices in the background (0 = scrape on request)")
+	maxConcurrent      = flag.Int("scrape.max-concurrent", 0, "Maximum number of devices to scrape at the same time (0 = unlimited)")
+	minLoginInterval   = flag.Duration("ssh.min-login-interval", 0, "Minimum time between two SSH logins to the same device")
+	idleTimeout        = flag.Duration("ssh.idle-timeout", 10*time.Minute, "Close connections not used for this time (0 = keep open)")
 	debug              = flag.Bool("debug", false, "Show verbose debug output in log")
 	legacyCiphers      = flag.Bool("legacy.ciphers", false, "Allow legacy CBC ciphers")
 	bgpEnabled         = flag.Bool("bgp.enabled", true, "Scrape bgp metrics")
 	environmentEnabled = flag.Bool("environment.enabled", true, "Scrape environment metrics")
 	factsEnabled       = flag.Bool("facts.enabled", true, "Scrape system metrics")
+	firewallEnabled    = flag.Bool("firewall.enabled", true, "Scrape firewall metrics")
 	interfacesEnabled  = flag.Bool("interfaces.enabled", true, "Scrape interface metrics")
 	opticsEnabled      = flag.Bool("optics.enabled", true, "Scrape optic metrics")
 	configFile         = flag.String("config.file", "", "Path to config file")
 	devices            []*connector.Device
+	connManager        = connector.NewConnectionManager()
+	cache              = newCollectorCache()
+	scraper            *backgroundScraper
+	limiter            *scrapeLimiter
 	cfg                *config.Config
+	staticCfg          *config.Config
+	staticDevices      []*connector.Device
+	secretStore        *secrets.Store
+	stateMu            sync.RWMutex
 )
 
 func init() {
@@ -55,11 +79,12 @@ func main() {
 		os.Exit(0)
 	}
 
-	err := initialize()
+	err := reloadConfig()
 	if err != nil {
 		log.Fatalf("could not initialize exporter. %v", err)
 	}
 
+	go reloadOnSignal()
 	startServer()
 }
 
@@ -84,35 +109,131 @@ func initialize() error {
 		return err
 	}
 
-	devices, err = devicesForConfig(c)
+	// devices of discoverers removed from the config must not be applied again
+	if len(discovery.DiscoverersForConfig(c)) == 0 {
+		discoveredDevices = nil
+	}
+
+	err = applyConfig(c, discoveredDevices)
 	if err != nil {
 		return err
 	}
-	cfg = c
+
+	startDiscovery(c)
 
 	return nil
 }
 
+// applyConfig applies a config together with the devices found by service discovery.
+// Discovered devices which are configured statically or are invalid are skipped
+func applyConfig(c *config.Config, discovered []*config.DeviceConfig) error {
+	store := secrets.NewStore(secrets.ProvidersForConfig(c))
+	static, err := devicesForConfig(context.Background(), c, store)
+	if err != nil {
+		return err
+	}
+
+	effective, devs := withDiscoveredDevices(c, static, discovered, store)
+
+	stateMu.Lock()
+	staticCfg = c
+	staticDevices = static
+	cfg = effective
+	secretStore = store
+	devices = devs
+	limiter = newScrapeLimiter(cfg.MaxConcurrentScrapes)
+	restartScraper()
+	stateMu.Unlock()
+
+	connManager.Retain(devs, effective)
+	cache.retain(devs)
+
+	return nil
+}
+
+// applyDiscoveredDevices updates the devices found by service discovery. Unlike applyConfig, the secret store and
+// the scrape limiter are kept and the background scraper only starts and stops scraping of changed devices.
+// Has to be called while holding reloadMu
+func applyDiscoveredDevices(discovered []*config.DeviceConfig) {
+	effective, devs := withDiscoveredDevices(staticCfg, staticDevices, discovered, secretStore)
+
+	stateMu.Lock()
+	cfg = effective
+	devices = devs
+	if scraper != nil && !scraper.updateDevices(devices) {
+		restartScraper()
+	}
+	stateMu.Unlock()
+
+	connManager.Retain(devs, effective)
+	cache.retain(devs)
+}
+
+// withDiscoveredDevices returns the config and the devices of c extended by the discovered devices
+func withDiscoveredDevices(c *config.Config, static []*connector.Device, discovered []*config.DeviceConfig, store *secrets.Store) (*config.Config, []*connector.Device) {
+	effective := c.Copy()
+	devs := append([]*connector.Device{}, static...)
+	for _, dc := range discovered {
+		err := c.ValidateDevice(dc)
+		if err != nil {
+			log.Errorf("discovered %v", err)
+			continue
+		}
+		d, err := deviceFromDeviceConfig(context.Background(), dc, c, store)
+		if err != nil {
+			log.Errorln(err)
+			continue
+		}
+		if effective.AddDevice(dc) {
+			devs = append(devs, d)
+		}
+	}
+
+	return effective, devs
+}
+
+// restartScraper replaces the background scraper by one for the current devices. Has to be called while holding stateMu
+func restartScraper() {
+	previous := scraper
+	scraper = nil
+	if previous != nil {
+		previous.shutdown()
+	}
+	if cfg.ScrapeInterval > 0 {
+		scraper = newBackgroundScraper(devices)
+		scraper.inheritSnapshots(previous)
+		scraper.start()
+	}
+}
+
 func loadConfigFromFlags() *config.Config {
 	c := config.New()
 
 	c.Debug = *debug
 	c.LegacyCiphers = *legacyCiphers
 	c.Timeout = *sshTimeout
+	c.ScrapeInterval = *scrapeInterval
+	c.MaxConcurrentScrapes = *maxConcurrent
+	c.MinLoginInterval = *minLoginInterval
+	c.IdleTimeout = *idleTimeout
 	c.BatchSize = *sshBatchSize
 	c.Username = *sshUsername
 	c.Password = *sshPassword
+	c.PasswordFile = *sshPasswordFile
 
 	c.KeyFile = *sshKeyFile
+	c.KnownHostsFile = *sshKnownHostsFile
+	c.TrustOnFirstUse = *sshTrustOnFirstUse
 
 	c.DevicesFromTargets(*sshHosts)
 
 	f := c.Features
-	f.BGP = bgpEnabled
-	f.Environment = environmentEnabled
-	f.Facts = factsEnabled
-	f.Interfaces = interfacesEnabled
-	f.Optics = opticsEnabled
+	f.BGP = config.NewFeature(*bgpEnabled)
+	f.Environment = config.NewFeature(*environmentEnabled)
+	f.Facts = config.NewFeature(*factsEnabled)
+	f.Firewall = config.NewFeature(*firewallEnabled)
+	f.Interfaces = config.NewFeature(*interfacesEnabled)
+	f.Optics = config.NewFeature(*opticsEnabled)
 
 	return c
 }
@@ -132,12 +253,15 @@ func startServer() {
 			<body>
 			<h1>Cisco Exporter</h1>
 			<p><a href="` + *metricsPath + `">Metrics</a></p>
+			<p><a href="` + *probePath + `?target=host.example.com">Probe</a></p>
 			<h2>More information:</h2>
 			<p><a href="https://github.com/lwlcom/cisco_exporter">github.com/lwlcom/cisco_exporter</a></p>
 			</body>
 			</html>`))
 	})
 	http.HandleFunc(*metricsPath, handleMetricsRequest)
+	http.HandleFunc(*probePath, handleProbeRequest)
+	http.HandleFunc("/-/reload", handleReloadRequest)
 
 	log.Infof("Listening for %s on %s\n", *metricsPath, *listenAddress)
 	log.Fatal(http.ListenAndServe(*listenAddress, nil))
@@ -145,10 +269,84 @@ func startServer() {
 
 func handleMetricsRequest(w http.ResponseWriter, r *http.Request) {
 	reg := prometheus.NewRegistry()
+	reg.MustRegister(reloadSuccessGauge, reloadTimestampGauge)
+
+	ctx, cancel := scrapeContext(r)
+	defer cancel()
 
-	c := newCiscoCollector(devices)
+	stateMu.RLock()
+	if scraper != nil {
+		reg.MustRegister(scraper)
+	} else {
+		c := newCiscoCollector(ctx, devices)
+		reg.MustRegister(c)
+	}
+	stateMu.RUnlock()
+
+	serveRegistry(reg, w, r)
+}
+
+func handleProbeRequest(w http.ResponseWriter, r *http.Request) {
+	target := r.URL.Query().Get("target")
+	if len(target) == 0 {
+		http.Error(w, "target parameter is missing", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := scrapeContext(r)
+	defer cancel()
+
+	stateMu.RLock()
+	c, err := newProbeCollectorForTarget(ctx, target, r.URL.Query().Get("module"))
+	stateMu.RUnlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	reg := prometheus.NewRegistry()
 	reg.MustRegister(c)
 
+	serveRegistry(reg, w, r)
+}
+
+// scrapeContext creates the context for a scrape, which is done when the client went away or
+// the scrape timeout sent by Prometheus (minus the configured offset) is reached
+func scrapeContext(r *http.Request) (context.Context, context.CancelFunc) {
+	v := r.Header.Get("X-Prometheus-Scrape-Timeout-Seconds")
+	if len(v) == 0 {
+		return context.WithCancel(r.Context())
+	}
+
+	seconds, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		log.Errorf("invalid scrape timeout %q: %v", v, err)
+		return context.WithCancel(r.Context())
+	}
+
+	timeout := time.Duration(seconds*float64(time.Second)) - *timeoutOffset
+	if timeout <= 0 {
+		timeout = time.Duration(seconds * float64(time.Second))
+	}
+
+	return context.WithTimeout(r.Context(), timeout)
+}
+
+func newProbeCollectorForTarget(ctx context.Context, target, module string) (*ciscoCollector, error) {
+	features, err := cfg.FeaturesForProbe(target, module)
+	if err != nil {
+		return nil, err
+	}
+
+	device, err := deviceFromDeviceConfig(ctx, cfg.DeviceConfigForTarget(target), cfg, secretStore)
+	if err != nil {
+		return nil, err
+	}
+
+	return newProbeCollector(ctx, device, features), nil
+}
+
+func serveRegistry(reg *prometheus.Registry, w http.ResponseWriter, r *http.Request) {
 	l := log.New()
 	l.Level = log.ErrorLevel
 
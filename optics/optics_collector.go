@@ -1,6 +1,7 @@
 package optics
 
 import (
+	"context"
 	"log"
 	"regexp"
 
@@ -12,23 +13,20 @@ import (
 
 const prefix string = "cisco_optics_"
 
-var (
+type opticsCollector struct {
 	opticsTXDesc *prometheus.Desc
 	opticsRXDesc *prometheus.Desc
-)
-
-func init() {
-	l := []string{"target", "interface"}
-	opticsTXDesc = prometheus.NewDesc(prefix+"tx", "Transceiver Tx power", l, nil)
-	opticsRXDesc = prometheus.NewDesc(prefix+"rx", "Transceiver Rx power", l, nil)
 }
 
-type opticsCollector struct {
-}
+// NewCollector creates a new collector. labels are the names of the labels identifying the target
+func NewCollector(labels []string) collector.RPCCollector {
+	c := &opticsCollector{}
+
+	l := append(labels, "interface")
+	c.opticsTXDesc = prometheus.NewDesc(prefix+"tx", "Transceiver Tx power", l, nil)
+	c.opticsRXDesc = prometheus.NewDesc(prefix+"rx", "Transceiver Rx power", l, nil)
 
-// NewCollector creates a new collector
-func NewCollector() collector.RPCCollector {
-	return &opticsCollector{}
+	return c
 }
 
 // Name returns the name of the collector
@@ -37,22 +35,29 @@ func (*opticsCollector) Name() string {
 }
 
 // Describe describes the metrics
-func (*opticsCollector) Describe(ch chan<- *prometheus.Desc) {
-	ch <- opticsTXDesc
-	ch <- opticsRXDesc
+func (c *opticsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.opticsTXDesc
+	ch <- c.opticsRXDesc
 }
 
 // Collect collects metrics from Cisco
-func (c *opticsCollector) Collect(client *rpc.Client, ch chan<- prometheus.Metric, labelValues []string) error {
+func (c *opticsCollector) Collect(ctx context.Context, client *rpc.Client, ch chan<- prometheus.Metric, labelValues []string) error {
 	var iflistcmd string
 
 	switch client.OSType {
 	case rpc.IOS, rpc.IOSXE:
 		iflistcmd = "show interfaces stats | exclude disabled"
+	case rpc.IOSXR:
+		iflistcmd = "show interfaces brief"
 	case rpc.NXOS:
 		iflistcmd = "show interface status | exclude disabled | exclude notconn | exclude sfpAbsent | exclude --------------------------------------------------------------------------------"
+	default:
+		if client.Debug {
+			log.Printf("Optics are not implemented for %s (%s)\n", labelValues[0], client.OSType)
+		}
+		return nil
 	}
-	out, err := client.RunCommand(iflistcmd)
+	out, err := client.RunCommand(ctx, iflistcmd)
 
 	if err != nil {
 		return err
@@ -66,19 +71,26 @@ func (c *opticsCollector) Collect(client *rpc.Client, ch chan<- prometheus.Metri
 	}
 
 	xeDev, _ := regexp.Compile(`\S(\d+)/(\d+)/(\d+)`)
+	xrDev, _ := regexp.Compile(`(\d+/\d+/\d+/\d+)`)
 
 	for _, i := range interfaces {
 		switch client.OSType {
 		case rpc.IOS:
-			out, err = client.RunCommand("show interfaces " + i + " transceiver")
+			out, err = client.RunCommand(ctx, "show interfaces "+i+" transceiver")
 		case rpc.NXOS:
-			out, err = client.RunCommand("show interface " + i + " transceiver details")
+			out, err = client.RunCommand(ctx, "show interface "+i+" transceiver details")
+		case rpc.IOSXR:
+			matches := xrDev.FindStringSubmatch(i)
+			if matches == nil {
+				continue
+			}
+			out, err = client.RunCommand(ctx, "show controllers optics "+matches[1])
 		case rpc.IOSXE:
 			matches := xeDev.FindStringSubmatch(i)
 			if matches == nil {
 				continue
 			}
-			out, err = client.RunCommand("show hw-module subslot " + matches[1] + "/" + matches[2] + " transceiver " + matches[3] + " status")
+			out, err = client.RunCommand(ctx, "show hw-module subslot "+matches[1]+"/"+matches[2]+" transceiver "+matches[3]+" status")
 		}
 		if err != nil {
 			if client.Debug {
@@ -95,8 +107,8 @@ func (c *opticsCollector) Collect(client *rpc.Client, ch chan<- prometheus.Metri
 		}
 		l := append(labelValues, i)
 
-		ch <- prometheus.MustNewConstMetric(opticsTXDesc, prometheus.GaugeValue, float64(optic.TxPower), l...)
-		ch <- prometheus.MustNewConstMetric(opticsRXDesc, prometheus.GaugeValue, float64(optic.RxPower), l...)
+		ch <- prometheus.MustNewConstMetric(c.opticsTXDesc, prometheus.GaugeValue, float64(optic.TxPower), l...)
+		ch <- prometheus.MustNewConstMetric(c.opticsRXDesc, prometheus.GaugeValue, float64(optic.RxPower), l...)
 	}
 
 	return nil
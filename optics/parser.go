@@ -11,6 +11,9 @@ import (
 
 // ParseInterfaces parses cli output and returns list of interface names
 func (c *opticsCollector) ParseInterfaces(ostype string, output string) ([]string, error) {
+	if ostype == rpc.IOSXR {
+		return c.parseInterfacesXR(output), nil
+	}
 	if ostype != rpc.IOSXE && ostype != rpc.NXOS && ostype != rpc.IOS {
 		return nil, errors.New("'show interfaces stats' is not implemented for " + ostype)
 	}
@@ -29,6 +32,9 @@ func (c *opticsCollector) ParseInterfaces(ostype string, output string) ([]strin
 
 // ParseTransceiver parses cli output and tries to find tx/rx power for an interface
 func (c *opticsCollector) ParseTransceiver(ostype string, output string) (Optics, error) {
+	if ostype == rpc.IOSXR {
+		return c.parseOpticsXR(output)
+	}
 	if ostype != rpc.IOSXE && ostype != rpc.NXOS && ostype != rpc.IOS {
 		return Optics{}, errors.New("Transceiver data is not implemented for " + ostype)
 	}
@@ -46,3 +52,34 @@ func (c *opticsCollector) ParseTransceiver(ostype string, output string) (Optics
 		RxPower: util.Str2float64(matches[2]),
 	}, nil
 }
+
+// parseInterfacesXR parses the output of show interfaces brief on IOS-XR and returns the physical interfaces not shut down
+func (c *opticsCollector) parseInterfacesXR(output string) []string {
+	var items []string
+	interfaceRegexp := regexp.MustCompile(`^\s*((?:Gi|Te|TF|Fo|FH|Hu|TH|FO)\d+/\S+)\s+(\S+)\s+`)
+	for _, line := range strings.Split(output, "\n") {
+		matches := interfaceRegexp.FindStringSubmatch(line)
+		if matches == nil || matches[2] == "admin-down" {
+			continue
+		}
+		items = append(items, matches[1])
+	}
+	return items
+}
+
+// parseOpticsXR parses the output of show controllers optics on IOS-XR. Optics with multiple lanes
+// report the total power of all lanes
+func (c *opticsCollector) parseOpticsXR(output string) (Optics, error) {
+	txRegexp := regexp.MustCompile(`(?m)^\s*(?:Total|Actual) TX Power = (-?\d+\.\d+) dBm`)
+	rxRegexp := regexp.MustCompile(`(?m)^\s*(?:Total )?RX Power = (-?\d+\.\d+) dBm`)
+
+	tx := txRegexp.FindStringSubmatch(output)
+	rx := rxRegexp.FindStringSubmatch(output)
+	if tx == nil || rx == nil {
+		return Optics{}, errors.New("Transceiver not found")
+	}
+	return Optics{
+		TxPower: util.Str2float64(tx[1]),
+		RxPower: util.Str2float64(rx[1]),
+	}, nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	reloadMu             sync.Mutex
+	reloadSuccessGauge   prometheus.Gauge
+	reloadTimestampGauge prometheus.Gauge
+)
+
+func init() {
+	reloadSuccessGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: prefix + "exporter_config_last_reload_successful",
+		Help: "Last config reload was successful",
+	})
+	reloadTimestampGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: prefix + "exporter_config_last_reload_success_timestamp_seconds",
+		Help: "Time of the last successful config reload",
+	})
+}
+
+// reloadConfig loads the config and applies it. If the config is invalid, the current config is kept
+func reloadConfig() error {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	err := initialize()
+	if err != nil {
+		reloadSuccessGauge.Set(0)
+		return err
+	}
+
+	reloadSuccessGauge.Set(1)
+	reloadTimestampGauge.SetToCurrentTime()
+	return nil
+}
+
+func reloadOnSignal() {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	for range hup {
+		log.Infoln("Reloading config (SIGHUP)")
+		err := reloadConfig()
+		if err != nil {
+			log.Errorf("could not reload config. %v", err)
+		}
+	}
+}
+
+func handleReloadRequest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "only POST requests are allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	log.Infoln("Reloading config (/-/reload)")
+	err := reloadConfig()
+	if err != nil {
+		log.Errorf("could not reload config. %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
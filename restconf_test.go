@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/lwlcom/cisco_exporter/config"
+	"github.com/lwlcom/cisco_exporter/secrets"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// restconfResponses are recorded from the RESTCONF API of a Catalyst 9300 running IOS-XE 17.6 (shortened)
+var restconfResponses = map[string]string{
+	"ietf-yang-library:modules-state": `{
+  "ietf-yang-library:modules-state": {
+    "module-set-id": "a1f2d3c4",
+    "module": [
+      {"name": "Cisco-IOS-XE-native", "revision": "2021-07-01", "namespace": "http://cisco.com/ns/yang/Cisco-IOS-XE-native", "conformance-type": "implement"},
+      {"name": "Cisco-IOS-XE-interfaces-oper", "revision": "2021-07-01", "namespace": "http://cisco.com/ns/yang/Cisco-IOS-XE-interfaces-oper", "conformance-type": "implement"},
+      {"name": "Cisco-IOS-XE-device-hardware-oper", "revision": "2021-07-01", "namespace": "http://cisco.com/ns/yang/Cisco-IOS-XE-device-hardware-oper", "conformance-type": "implement"}
+    ]
+  }
+}`,
+	"Cisco-IOS-XE-interfaces-oper:interfaces": `{
+  "Cisco-IOS-XE-interfaces-oper:interfaces": {
+    "interface": [
+      {
+        "name": "GigabitEthernet1/0/1",
+        "interface-type": "iana-iftype-ethernet-csmacd",
+        "admin-status": "if-state-up",
+        "oper-status": "if-oper-state-ready",
+        "description": "uplink",
+        "phys-address": "00:1e:bd:4c:2a:01",
+        "speed": "1000000000",
+        "statistics": {
+          "in-octets": "123456789",
+          "in-unicast-pkts": "1000",
+          "in-broadcast-pkts": "10",
+          "in-multicast-pkts": "20",
+          "in-discards": 1,
+          "in-errors": 2,
+          "out-octets": "987654321",
+          "out-discards": "3",
+          "out-errors": "4"
+        }
+      }
+    ]
+  }
+}`,
+	"Cisco-IOS-XE-device-hardware-oper:device-hardware-data/device-hardware/device-system-data": `{
+  "Cisco-IOS-XE-device-hardware-oper:device-system-data": {
+    "current-time": "2021-11-02T10:15:04+00:00",
+    "boot-time": "2021-10-01T08:00:00+00:00",
+    "software-version": "Cisco IOS Software [Bengaluru], Catalyst L3 Switch Software (CAT9K_IOSXE), Version 17.6.1, RELEASE SOFTWARE (fc2)"
+  }
+}`,
+}
+
+func restconfServer(t *testing.T) (*httptest.Server, *[]string) {
+	var mu sync.Mutex
+	requests := make([]string, 0)
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/restconf/data/")
+		mu.Lock()
+		requests = append(requests, path)
+		mu.Unlock()
+
+		if user, password, _ := r.BasicAuth(); user != "exporter" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		body, found := restconfResponses[path]
+		if !found {
+			w.Header().Set("Content-Type", "application/yang-data+json")
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"ietf-restconf:errors": {"error": [{"error-type": "application", "error-tag": "invalid-value", "error-message": "uri keypath not found"}]}}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/yang-data+json")
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, &requests
+}
+
+func TestRestconfScrape(t *testing.T) {
+	srv, requests := restconfServer(t)
+
+	c, err := config.Load(strings.NewReader(`
+username: exporter
+Password: secret
+tls:
+  insecure_skip_verify: true
+features: {bgp: false, environment: false, facts: true, firewall: true, interfaces: true, optics: true}
+devices:
+  - host: ` + strings.TrimPrefix(srv.URL, "https://") + `
+    transport: restconf
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	devs, err := devicesForConfig(context.Background(), c, secrets.NewStore(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg = c
+	limiter = newScrapeLimiter(0)
+	defer connManager.Retain(nil, c)
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(newCiscoCollector(context.Background(), devs))
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := make(map[string]float64)
+	collectors := make([]string, 0)
+	for _, f := range families {
+		for _, m := range f.Metric {
+			values[f.GetName()] = m.GetGauge().GetValue()
+			if f.GetName() != "cisco_collect_duration_seconds" {
+				continue
+			}
+			for _, l := range m.Label {
+				if l.GetName() == "collector" {
+					collectors = append(collectors, l.GetValue())
+				}
+			}
+		}
+	}
+	expected := map[string]float64{
+		"cisco_up":                        1,
+		"cisco_interface_receive_bytes":   123456789,
+		"cisco_interface_receive_drops":   1,
+		"cisco_interface_transmit_errors": 4,
+		"cisco_facts_version":             1,
+	}
+	for name, v := range expected {
+		if values[name] != v {
+			t.Errorf("expected %s to be %v, got %v", name, v, values[name])
+		}
+	}
+
+	// collectors without a model supported by the device are not run as there is no CLI to fall back to
+	sort.Strings(collectors)
+	if strings.Join(collectors, ",") != "Facts,Interfaces" {
+		t.Errorf("unexpected collectors run: %v", collectors)
+	}
+	for _, r := range *requests {
+		if _, found := restconfResponses[r]; !found {
+			t.Errorf("unexpected request for %s", r)
+		}
+	}
+}
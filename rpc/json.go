@@ -0,0 +1,84 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNoJSON is returned by RunCommandJSON if the device did not answer with JSON output, e.g. as the command
+// does not support it on the running version
+var ErrNoJSON = errors.New("no JSON output")
+
+// RunCommandJSON runs a command with JSON output (cmd | json) and decodes the output into v.
+// NX-OS encodes tables as TABLE_x: {ROW_x: [...]} with a single object instead of the list if there is only one row,
+// these are always decoded as list. Commands without JSON output are remembered in NoJSON and not run again
+func (c *Client) RunCommandJSON(ctx context.Context, cmd string, v interface{}) error {
+	if c.NoJSON[cmd] {
+		return fmt.Errorf("%w: not supported by %s", ErrNoJSON, cmd)
+	}
+
+	err := c.runCommandJSON(ctx, cmd, v)
+	if errors.Is(err, ErrNoJSON) && c.NoJSON != nil {
+		c.NoJSON[cmd] = true
+	}
+
+	return err
+}
+
+func (c *Client) runCommandJSON(ctx context.Context, cmd string, v interface{}) error {
+	out, err := c.RunCommand(ctx, cmd+" | json")
+	if err != nil {
+		return err
+	}
+
+	start := strings.Index(out, "{")
+	end := strings.LastIndex(out, "}")
+	if start < 0 || end < start {
+		return ErrNoJSON
+	}
+
+	d := json.NewDecoder(strings.NewReader(out[start : end+1]))
+	d.UseNumber()
+
+	var data interface{}
+	err = d.Decode(&data)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrNoJSON, err)
+	}
+
+	b, err := json.Marshal(rowsAsList(data))
+	if err != nil {
+		return err
+	}
+
+	err = json.NewDecoder(bytes.NewReader(b)).Decode(v)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrNoJSON, err)
+	}
+
+	return nil
+}
+
+// rowsAsList replaces single objects in ROW_ elements by a list containing the object
+func rowsAsList(data interface{}) interface{} {
+	switch d := data.(type) {
+	case map[string]interface{}:
+		for k, v := range d {
+			v = rowsAsList(v)
+			if _, isObject := v.(map[string]interface{}); isObject && strings.HasPrefix(k, "ROW_") {
+				v = []interface{}{v}
+			}
+			d[k] = v
+		}
+	case []interface{}:
+		for i, v := range d {
+			d[i] = rowsAsList(v)
+		}
+	}
+
+	return data
+}
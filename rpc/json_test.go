@@ -0,0 +1,62 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/lwlcom/cisco_exporter/connector"
+)
+
+// fakeTransport answers commands with fixed outputs and records the commands run
+type fakeTransport struct {
+	connector.Transport
+	outputs  map[string]string
+	commands []string
+}
+
+func (t *fakeTransport) Host() string { return "fake" }
+
+func (t *fakeTransport) RunCommand(ctx context.Context, cmd string) (string, error) {
+	t.commands = append(t.commands, cmd)
+	return t.outputs[cmd], nil
+}
+
+func TestRunCommandJSON(t *testing.T) {
+	conn := &fakeTransport{outputs: map[string]string{
+		"show version | json":     `{"TABLE_module": {"ROW_module": {"modelnum": "N9K-C93180YC-EX"}}}`,
+		"show environment | json": "                      ^\n% Invalid command at '^' marker.\n",
+	}}
+	c := NewClient(conn, false)
+	c.NoJSON = make(map[string]bool)
+
+	var version struct {
+		Modules struct {
+			Rows []struct {
+				Model string `json:"modelnum"`
+			} `json:"ROW_module"`
+		} `json:"TABLE_module"`
+	}
+	err := c.RunCommandJSON(context.Background(), "show version", &version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(version.Modules.Rows) != 1 || version.Modules.Rows[0].Model != "N9K-C93180YC-EX" {
+		t.Fatalf("unexpected output %+v", version)
+	}
+
+	for i := 0; i < 2; i++ {
+		var env struct{}
+		err = c.RunCommandJSON(context.Background(), "show environment", &env)
+		if !errors.Is(err, ErrNoJSON) {
+			t.Fatalf("expected ErrNoJSON, got %v", err)
+		}
+	}
+
+	if len(conn.commands) != 2 {
+		t.Fatalf("command without JSON output was run again: %v", conn.commands)
+	}
+	if !c.NoJSON["show environment"] || c.NoJSON["show version"] {
+		t.Fatalf("unexpected commands without JSON output %v", c.NoJSON)
+	}
+}
@@ -0,0 +1,304 @@
+package rpc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	netconfNamespace = "urn:ietf:params:xml:ns:netconf:base:1.0"
+	netconfBase10    = "urn:ietf:params:netconf:base:1.0"
+	netconfBase11    = "urn:ietf:params:netconf:base:1.1"
+	netconfEOM       = "]]>]]>"
+)
+
+// NetconfSession is a NETCONF session (RFC 6241) to a device. Messages are framed by end-of-message markers
+// until both sides announced base:1.1 in their hello, chunked framing (RFC 6242) is used afterwards
+type NetconfSession struct {
+	conn          io.ReadWriteCloser
+	r             *bufio.Reader
+	timeout       time.Duration
+	maxOutputSize int
+	modules       map[string]bool
+	chunked       bool
+	messageID     int
+	broken        bool
+}
+
+type netconfHello struct {
+	XMLName      xml.Name `xml:"urn:ietf:params:xml:ns:netconf:base:1.0 hello"`
+	Capabilities []string `xml:"capabilities>capability"`
+	SessionID    int      `xml:"session-id,omitempty"`
+}
+
+type netconfReply struct {
+	XMLName   xml.Name       `xml:"rpc-reply"`
+	MessageID string         `xml:"message-id,attr"`
+	Errors    []netconfError `xml:"rpc-error"`
+	Data      struct {
+		Inner []byte `xml:",innerxml"`
+	} `xml:"data"`
+}
+
+type netconfError struct {
+	Type     string `xml:"error-type"`
+	Tag      string `xml:"error-tag"`
+	Severity string `xml:"error-severity"`
+	Message  string `xml:"error-message"`
+}
+
+func (e netconfError) Error() string {
+	msg := strings.TrimSpace(e.Message)
+	if len(msg) == 0 {
+		msg = e.Tag
+	}
+
+	return fmt.Sprintf("netconf %s error: %s", e.Type, msg)
+}
+
+// NewNetconfSession starts a NETCONF session on conn by exchanging the hello messages. Every exchange of messages
+// has to be completed within timeout and replies larger than maxOutputSize are rejected (0 = no limit)
+func NewNetconfSession(ctx context.Context, conn io.ReadWriteCloser, timeout time.Duration, maxOutputSize int) (*NetconfSession, error) {
+	s := &NetconfSession{
+		conn:          conn,
+		r:             bufio.NewReader(conn),
+		timeout:       timeout,
+		maxOutputSize: maxOutputSize,
+		modules:       make(map[string]bool),
+	}
+
+	hello, err := xml.Marshal(&netconfHello{Capabilities: []string{netconfBase10, netconfBase11}})
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := s.exchange(ctx, []byte(xml.Header+string(hello)))
+	if err != nil {
+		return nil, errors.Wrap(err, "netconf hello failed")
+	}
+
+	var serverHello netconfHello
+	err = xml.Unmarshal(b, &serverHello)
+	if err != nil {
+		s.broken = true
+		return nil, errors.Wrap(err, "could not parse netconf hello")
+	}
+
+	for _, c := range serverHello.Capabilities {
+		c = strings.TrimSpace(c)
+		if c == netconfBase11 {
+			s.chunked = true
+		}
+		if module := moduleFromCapability(c); len(module) > 0 {
+			s.modules[module] = true
+		}
+	}
+
+	return s, nil
+}
+
+// moduleFromCapability gets the name of the YANG module announced by a capability (e.g. ...?module=Cisco-IOS-XE-interfaces-oper&revision=...)
+func moduleFromCapability(capability string) string {
+	i := strings.Index(capability, "?")
+	if i < 0 {
+		return ""
+	}
+
+	q, err := url.ParseQuery(strings.Replace(capability[i+1:], "&amp;", "&", -1))
+	if err != nil {
+		return ""
+	}
+
+	return q.Get("module")
+}
+
+// HasModule checks if the device announced support for a YANG module
+func (s *NetconfSession) HasModule(module string) bool {
+	return s.modules[module]
+}
+
+// IsBroken checks if the session is in an unknown state after an error and has to be closed
+func (s *NetconfSession) IsBroken() bool {
+	return s.broken
+}
+
+// Get retrieves the operational data selected by the subtree filter and decodes the content of the data element into v
+func (s *NetconfSession) Get(ctx context.Context, filter string, v interface{}) error {
+	data, err := s.rpc(ctx, `<get><filter type="subtree">`+filter+`</filter></get>`)
+	if err != nil {
+		return err
+	}
+
+	data = append(append([]byte("<data>"), data...), "</data>"...)
+	return errors.Wrap(xml.Unmarshal(data, v), "could not parse netconf reply")
+}
+
+// Close ends the session and closes the connection
+func (s *NetconfSession) Close() error {
+	if !s.broken {
+		s.write([]byte(`<rpc message-id="` + strconv.Itoa(s.messageID+1) + `" xmlns="` + netconfNamespace + `"><close-session/></rpc>`))
+	}
+
+	return s.conn.Close()
+}
+
+// rpc sends an operation and returns the content of the data element of the reply
+func (s *NetconfSession) rpc(ctx context.Context, operation string) ([]byte, error) {
+	s.messageID++
+	id := strconv.Itoa(s.messageID)
+
+	b, err := s.exchange(ctx, []byte(`<rpc message-id="`+id+`" xmlns="`+netconfNamespace+`">`+operation+`</rpc>`))
+	if err != nil {
+		return nil, err
+	}
+
+	var reply netconfReply
+	err = xml.Unmarshal(b, &reply)
+	if err != nil {
+		s.broken = true
+		return nil, errors.Wrap(err, "could not parse netconf reply")
+	}
+	if reply.MessageID != id {
+		s.broken = true
+		return nil, fmt.Errorf("unexpected netconf reply to message %s, expected %s", reply.MessageID, id)
+	}
+
+	for _, e := range reply.Errors {
+		if e.Severity != "warning" {
+			return nil, e
+		}
+	}
+
+	return reply.Data.Inner, nil
+}
+
+type netconfResult struct {
+	msg []byte
+	err error
+}
+
+// exchange sends a message and reads the reply. If the timeout is exceeded or the context is done before,
+// the connection is closed as the session is in an unknown state afterwards
+func (s *NetconfSession) exchange(ctx context.Context, msg []byte) ([]byte, error) {
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	ch := make(chan netconfResult, 1)
+	go func() {
+		err := s.write(msg)
+		if err != nil {
+			ch <- netconfResult{err: err}
+			return
+		}
+		b, err := s.read()
+		ch <- netconfResult{msg: b, err: err}
+	}()
+
+	select {
+	case res := <-ch:
+		if res.err != nil {
+			s.broken = true
+		}
+		return res.msg, res.err
+	case <-ctx.Done():
+		s.broken = true
+		s.conn.Close()
+		return nil, ctx.Err()
+	}
+}
+
+func (s *NetconfSession) write(msg []byte) error {
+	var err error
+	if s.chunked {
+		_, err = fmt.Fprintf(s.conn, "\n#%d\n%s\n##\n", len(msg), msg)
+	} else {
+		_, err = fmt.Fprintf(s.conn, "%s%s", msg, netconfEOM)
+	}
+
+	return err
+}
+
+func (s *NetconfSession) read() ([]byte, error) {
+	if s.chunked {
+		return s.readChunked()
+	}
+
+	return s.readEOM()
+}
+
+func (s *NetconfSession) readEOM() ([]byte, error) {
+	var msg []byte
+	for {
+		b, err := s.r.ReadBytes('>')
+		if err != nil {
+			return nil, err
+		}
+
+		msg = append(msg, b...)
+		if s.exceedsMaxOutputSize(0, len(msg)-len(netconfEOM)) {
+			return nil, s.maxOutputSizeErr()
+		}
+		if bytes.HasSuffix(msg, []byte(netconfEOM)) {
+			return bytes.TrimSpace(msg[:len(msg)-len(netconfEOM)]), nil
+		}
+	}
+}
+
+func (s *NetconfSession) readChunked() ([]byte, error) {
+	var msg []byte
+	for {
+		header, err := s.r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		if header == "\n" {
+			continue
+		}
+
+		header = strings.TrimSuffix(header, "\n")
+		if header == "##" {
+			return msg, nil
+		}
+		if !strings.HasPrefix(header, "#") {
+			return nil, fmt.Errorf("invalid netconf chunk header %q", header)
+		}
+
+		size, err := strconv.Atoi(header[1:])
+		if err != nil || size <= 0 {
+			return nil, fmt.Errorf("invalid netconf chunk size %q", header[1:])
+		}
+
+		if s.exceedsMaxOutputSize(len(msg), size) {
+			return nil, s.maxOutputSizeErr()
+		}
+
+		chunk := make([]byte, size)
+		_, err = io.ReadFull(s.r, chunk)
+		if err != nil {
+			return nil, err
+		}
+		msg = append(msg, chunk...)
+	}
+}
+
+// exceedsMaxOutputSize checks if a reply of size bytes can not be extended by additional bytes
+func (s *NetconfSession) exceedsMaxOutputSize(size, additional int) bool {
+	return s.maxOutputSize > 0 && additional > s.maxOutputSize-size
+}
+
+func (s *NetconfSession) maxOutputSizeErr() error {
+	return fmt.Errorf("netconf reply exceeds maximum size of %d bytes", s.maxOutputSize)
+}
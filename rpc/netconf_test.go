@@ -0,0 +1,305 @@
+package rpc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var messageIDRegexp = regexp.MustCompile(`message-id="(\d+)"`)
+
+// netconfStub is an in-process NETCONF server answering get operations with a memory statistic.
+// Filters for hang, large and huge are not answered, answered with 4KB of data or with an oversized chunk header.
+// Chunked framing is used after the hello exchange if base11 is set
+type netconfStub struct {
+	conn   net.Conn
+	r      *bufio.Reader
+	base11 bool
+	hello  chan []byte
+}
+
+func startNetconfStub(t *testing.T, base11 bool, serverHello string) (*netconfStub, net.Conn) {
+	client, server := net.Pipe()
+	s := &netconfStub{
+		conn:   server,
+		r:      bufio.NewReader(server),
+		base11: base11,
+		hello:  make(chan []byte, 1),
+	}
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+
+	go s.serve(serverHello)
+
+	return s, client
+}
+
+func (s *netconfStub) serve(serverHello string) {
+	capabilities := `<capability>urn:ietf:params:netconf:base:1.0</capability>`
+	if s.base11 {
+		capabilities += `<capability>urn:ietf:params:netconf:base:1.1</capability>`
+	}
+	capabilities += `<capability>http://cisco.com/ns/yang/Cisco-IOS-XE-memory-oper?module=Cisco-IOS-XE-memory-oper&amp;revision=2018-04-25</capability>`
+	if len(serverHello) == 0 {
+		serverHello = `<hello xmlns="urn:ietf:params:xml:ns:netconf:base:1.0"><capabilities>` + capabilities + `</capabilities><session-id>42</session-id></hello>`
+	}
+
+	// both sides send their hello at the same time and net.Pipe is not buffered
+	go io.WriteString(s.conn, xml.Header+serverHello+netconfEOM)
+	hello, err := s.readEOM()
+	if err != nil {
+		return
+	}
+	s.hello <- hello
+
+	for {
+		var msg []byte
+		if s.base11 {
+			msg, err = s.readChunked()
+		} else {
+			msg, err = s.readEOM()
+		}
+		if err != nil {
+			return
+		}
+
+		m := messageIDRegexp.FindSubmatch(msg)
+		if m == nil {
+			return
+		}
+
+		var reply string
+		switch {
+		case bytes.Contains(msg, []byte("<hang/>")):
+			continue
+		case bytes.Contains(msg, []byte("<huge/>")):
+			io.WriteString(s.conn, "\n#999999999999\n")
+			continue
+		case bytes.Contains(msg, []byte("<large/>")):
+			reply = `<data>` + strings.Repeat("<x/>", 1024) + `</data>`
+		case bytes.Contains(msg, []byte("<close-session/>")):
+			reply = `<ok/>`
+		case bytes.Contains(msg, []byte("memory-statistics")):
+			reply = `<data><memory-statistics xmlns="http://cisco.com/ns/yang/Cisco-IOS-XE-memory-oper">` +
+				`<memory-statistic><name>Processor</name><total-memory>1000</total-memory><used-memory>250</used-memory></memory-statistic>` +
+				`</memory-statistics></data>`
+		default:
+			reply = `<rpc-error><error-type>application</error-type><error-tag>operation-failed</error-tag>` +
+				`<error-severity>error</error-severity><error-message>unknown element</error-message></rpc-error>`
+		}
+		s.write(`<rpc-reply message-id="` + string(m[1]) + `" xmlns="urn:ietf:params:xml:ns:netconf:base:1.0">` + reply + `</rpc-reply>`)
+	}
+}
+
+// write sends msg, with chunked framing it is split into chunks of at most 64 bytes
+func (s *netconfStub) write(msg string) {
+	if !s.base11 {
+		io.WriteString(s.conn, msg+netconfEOM)
+		return
+	}
+
+	var b strings.Builder
+	for len(msg) > 0 {
+		n := 64
+		if len(msg) < n {
+			n = len(msg)
+		}
+		fmt.Fprintf(&b, "\n#%d\n%s", n, msg[:n])
+		msg = msg[n:]
+	}
+	b.WriteString("\n##\n")
+	io.WriteString(s.conn, b.String())
+}
+
+func (s *netconfStub) readEOM() ([]byte, error) {
+	var msg []byte
+	for !bytes.HasSuffix(msg, []byte(netconfEOM)) {
+		b, err := s.r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		msg = append(msg, b)
+	}
+
+	return msg[:len(msg)-len(netconfEOM)], nil
+}
+
+func (s *netconfStub) readChunked() ([]byte, error) {
+	var msg []byte
+	for {
+		header, err := s.r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		header = strings.TrimSpace(header)
+		switch {
+		case len(header) == 0:
+		case header == "##":
+			return msg, nil
+		case strings.HasPrefix(header, "#"):
+			size, err := strconv.Atoi(header[1:])
+			if err != nil {
+				return nil, err
+			}
+			chunk := make([]byte, size)
+			if _, err = io.ReadFull(s.r, chunk); err != nil {
+				return nil, err
+			}
+			msg = append(msg, chunk...)
+		default:
+			return nil, fmt.Errorf("invalid chunk header %q", header)
+		}
+	}
+}
+
+type memoryStatistics struct {
+	Statistics []struct {
+		Name        string `xml:"name"`
+		TotalMemory Number `xml:"total-memory"`
+		UsedMemory  Number `xml:"used-memory"`
+	} `xml:"memory-statistics>memory-statistic"`
+}
+
+const memoryFilter = `<memory-statistics xmlns="http://cisco.com/ns/yang/Cisco-IOS-XE-memory-oper"/>`
+
+func TestNetconfSession(t *testing.T) {
+	for _, base11 := range []bool{false, true} {
+		t.Run(fmt.Sprintf("base11=%v", base11), func(t *testing.T) {
+			stub, conn := startNetconfStub(t, base11, "")
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			s, err := NewNetconfSession(ctx, conn, 5*time.Second, 1024)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer s.Close()
+
+			var hello struct {
+				XMLName      xml.Name
+				Capabilities []string `xml:"capabilities>capability"`
+			}
+			if err = xml.Unmarshal(<-stub.hello, &hello); err != nil {
+				t.Fatal(err)
+			}
+			if hello.XMLName.Space != netconfNamespace || hello.XMLName.Local != "hello" {
+				t.Fatalf("unexpected hello element %v", hello.XMLName)
+			}
+			if strings.Join(hello.Capabilities, " ") != netconfBase10+" "+netconfBase11 {
+				t.Fatalf("unexpected capabilities %v", hello.Capabilities)
+			}
+
+			if s.chunked != base11 {
+				t.Fatalf("expected chunked framing %v, got %v", base11, s.chunked)
+			}
+			if !s.HasModule("Cisco-IOS-XE-memory-oper") {
+				t.Fatal("module announced in hello not found")
+			}
+
+			var data memoryStatistics
+			if err = s.Get(ctx, memoryFilter, &data); err != nil {
+				t.Fatal(err)
+			}
+			if len(data.Statistics) != 1 || data.Statistics[0].Name != "Processor" || data.Statistics[0].UsedMemory != 250 {
+				t.Fatalf("unexpected data %+v", data)
+			}
+
+			err = s.Get(ctx, `<unknown/>`, &data)
+			if err == nil || !strings.Contains(err.Error(), "unknown element") {
+				t.Fatalf("expected rpc error, got %v", err)
+			}
+			if s.IsBroken() {
+				t.Fatal("session broken by rpc error")
+			}
+
+			if err = s.Get(ctx, memoryFilter, &data); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestNetconfSessionHelloWithoutNamespace(t *testing.T) {
+	_, conn := startNetconfStub(t, false, `<hello><capabilities><capability>urn:ietf:params:netconf:base:1.0</capability></capabilities></hello>`)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := NewNetconfSession(ctx, conn, 5*time.Second, 1024)
+	if err == nil {
+		t.Fatal("expected error for hello without netconf namespace")
+	}
+}
+
+func TestGetOperWithoutSession(t *testing.T) {
+	c := &Client{OSType: IOSXE}
+
+	var data memoryStatistics
+	if err := c.GetOper(context.Background(), memoryFilter, "", &data); err == nil {
+		t.Fatal("expected error without NETCONF or RESTCONF session")
+	}
+}
+
+func TestNetconfSessionTimeout(t *testing.T) {
+	_, conn := startNetconfStub(t, false, "")
+
+	s, err := NewNetconfSession(context.Background(), conn, 100*time.Millisecond, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var data struct{}
+	err = s.Get(context.Background(), `<hang/>`, &data)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected timeout, got %v", err)
+	}
+	if !s.IsBroken() {
+		t.Fatal("session not broken after timeout")
+	}
+}
+
+func TestNetconfSessionMaxOutputSize(t *testing.T) {
+	tests := []struct {
+		base11 bool
+		filter string
+	}{
+		{false, `<large/>`},
+		{true, `<large/>`},
+		{true, `<huge/>`},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("base11=%v,%s", test.base11, test.filter), func(t *testing.T) {
+			_, conn := startNetconfStub(t, test.base11, "")
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			s, err := NewNetconfSession(ctx, conn, 5*time.Second, 1024)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var data struct{}
+			err = s.Get(ctx, test.filter, &data)
+			if err == nil || !strings.Contains(err.Error(), "exceeds maximum size") {
+				t.Fatalf("expected error for reply exceeding maximum size, got %v", err)
+			}
+			if !s.IsBroken() {
+				t.Fatal("session not broken after too large reply")
+			}
+		})
+	}
+}
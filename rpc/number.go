@@ -0,0 +1,36 @@
+package rpc
+
+import (
+	"bytes"
+	"strconv"
+)
+
+// Number is a numeric value of a YANG model. 64 bit integers and decimals are encoded as JSON strings (RFC 7951),
+// so both JSON numbers and strings are accepted
+type Number float64
+
+// UnmarshalJSON decodes a JSON number or string
+func (n *Number) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(b, []byte("null")) {
+		return nil
+	}
+
+	return n.UnmarshalText(bytes.Trim(b, `"`))
+}
+
+// UnmarshalText decodes the text of a XML element
+func (n *Number) UnmarshalText(b []byte) error {
+	b = bytes.TrimSpace(b)
+	if len(b) == 0 {
+		*n = 0
+		return nil
+	}
+
+	f, err := strconv.ParseFloat(string(b), 64)
+	if err != nil {
+		return err
+	}
+	*n = Number(f)
+
+	return nil
+}
@@ -1,6 +1,8 @@
 package rpc
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
@@ -12,52 +14,84 @@ import (
 
 const (
 	IOSXE string = "IOSXE"
+	IOSXR string = "IOSXR"
 	NXOS  string = "NXOS"
 	IOS   string = "IOS"
+	ASA   string = "ASA"
 )
 
 // Client sends commands to a Cisco device
 type Client struct {
-	conn   *connector.SSHConnection
-	Debug  bool
-	OSType string
+	conn     connector.Transport
+	Netconf  *NetconfSession
+	Restconf *connector.RestconfConnection
+	Debug    bool
+	OSType   string
+
+	// NoJSON holds the commands without JSON output, these are not run with JSON output again if set
+	NoJSON map[string]bool
 }
 
 // NewClient creates a new client connection
-func NewClient(ssh *connector.SSHConnection, debug bool) *Client {
-	rpc := &Client{conn: ssh, Debug: debug}
+func NewClient(conn connector.Transport, debug bool) *Client {
+	rpc := &Client{conn: conn, Debug: debug}
+	rpc.Restconf, _ = conn.(*connector.RestconfConnection)
 
 	return rpc
 }
 
 // Identify tries to identify the OS running on a Cisco device
-func (c *Client) Identify() error {
-	output, err := c.RunCommand("show version")
+func (c *Client) Identify(ctx context.Context) error {
+	if c.Restconf != nil {
+		return c.identifyByModules()
+	}
+
+	output, err := c.RunCommand(ctx, "show version")
 	if err != nil {
 		return err
 	}
 	switch {
 	case strings.Contains(output, "IOS XE"):
 		c.OSType = IOSXE
+	case strings.Contains(output, "IOS XR"):
+		c.OSType = IOSXR
 	case strings.Contains(output, "NX-OS"):
 		c.OSType = NXOS
+	case strings.Contains(output, "Adaptive Security Appliance"), strings.Contains(output, "Threat Defense"):
+		c.OSType = ASA
 	case strings.Contains(output, "IOS Software"):
 		c.OSType = IOS
 	default:
 		return errors.New("Unknown OS")
 	}
 	if c.Debug {
-		log.Printf("Host %s identified as: %s\n", c.conn.Host, c.OSType)
+		log.Printf("Host %s identified as: %s\n", c.conn.Host(), c.OSType)
+	}
+	return nil
+}
+
+// identifyByModules identifies the OS by the native YANG model supported by the RESTCONF API
+func (c *Client) identifyByModules() error {
+	switch {
+	case c.Restconf.HasModule("Cisco-IOS-XE-native"):
+		c.OSType = IOSXE
+	case c.Restconf.HasModule("Cisco-NX-OS-device"):
+		c.OSType = NXOS
+	default:
+		return errors.New("Unknown OS")
+	}
+	if c.Debug {
+		log.Printf("Host %s identified as: %s\n", c.conn.Host(), c.OSType)
 	}
 	return nil
 }
 
 // RunCommand runs a command on a Cisco device
-func (c *Client) RunCommand(cmd string) (string, error) {
+func (c *Client) RunCommand(ctx context.Context, cmd string) (string, error) {
 	if c.Debug {
-		log.Printf("Running command on %s: %s\n", c.conn.Host, cmd)
+		log.Printf("Running command on %s: %s\n", c.conn.Host(), cmd)
 	}
-	output, err := c.conn.RunCommand(fmt.Sprintf("%s", cmd))
+	output, err := c.conn.RunCommand(ctx, fmt.Sprintf("%s", cmd))
 	if err != nil {
 		println(err.Error())
 		return "", err
@@ -65,3 +99,42 @@ func (c *Client) RunCommand(cmd string) (string, error) {
 
 	return output, nil
 }
+
+// HasModule checks if the YANG module can be used to get data from the device by NETCONF or RESTCONF
+func (c *Client) HasModule(module string) bool {
+	if c.Restconf != nil {
+		return c.Restconf.HasModule(module)
+	}
+
+	return c.Netconf != nil && c.Netconf.HasModule(module)
+}
+
+// GetOper gets operational data from the device and decodes it into v. The data is selected by the subtree filter
+// when using NETCONF or by the resource path (e.g. Cisco-IOS-XE-interfaces-oper:interfaces) when using RESTCONF
+func (c *Client) GetOper(ctx context.Context, filter, path string, v interface{}) error {
+	if c.Restconf == nil {
+		if c.Netconf == nil {
+			return errors.New("no NETCONF or RESTCONF session to get operational data")
+		}
+		if c.Debug {
+			log.Printf("Running NETCONF get on %s: %s\n", c.conn.Host(), filter)
+		}
+
+		return c.Netconf.Get(ctx, filter, v)
+	}
+
+	if c.Debug {
+		log.Printf("Running RESTCONF get on %s: %s\n", c.conn.Host(), path)
+	}
+	b, err := c.Restconf.Get(ctx, path)
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(b, v)
+	if err != nil {
+		return fmt.Errorf("could not parse restconf reply: %s", err)
+	}
+
+	return nil
+}
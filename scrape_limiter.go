@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// scrapeLimiter limits the number of devices scraped at the same time
+type scrapeLimiter struct {
+	slots chan struct{}
+}
+
+func newScrapeLimiter(max int) *scrapeLimiter {
+	l := &scrapeLimiter{}
+	if max > 0 {
+		l.slots = make(chan struct{}, max)
+	}
+
+	return l
+}
+
+// acquire waits for a free slot and returns the time spent waiting
+func (l *scrapeLimiter) acquire(ctx context.Context) (time.Duration, error) {
+	if l.slots == nil {
+		return 0, nil
+	}
+
+	t := time.Now()
+	select {
+	case l.slots <- struct{}{}:
+		return time.Since(t), nil
+	case <-ctx.Done():
+		return time.Since(t), ctx.Err()
+	}
+}
+
+func (l *scrapeLimiter) release() {
+	if l.slots == nil {
+		return
+	}
+
+	<-l.slots
+}
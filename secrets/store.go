@@ -0,0 +1,97 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/lwlcom/cisco_exporter/config"
+)
+
+// Provider reads secrets from an external secret store
+type Provider interface {
+	// Name returns the name used to refer to the provider in secret references
+	Name() string
+
+	// Read returns all key value pairs of the secret at path
+	Read(ctx context.Context, path string) (map[string]string, error)
+}
+
+// Store resolves secret references of the form <provider>:<path>#<key>.
+// Every secret is read once and cached for the lifetime of the store, so a new store has to be created
+// to read secrets again (e.g. on config reload)
+type Store struct {
+	providers map[string]Provider
+	cache     map[string]map[string]string
+	mu        sync.Mutex
+}
+
+// ProvidersForConfig creates the providers configured
+func ProvidersForConfig(cfg *config.Config) []Provider {
+	providers := make([]Provider, 0)
+	if cfg.Vault != nil {
+		providers = append(providers, NewVaultProvider(cfg.Vault))
+	}
+
+	return providers
+}
+
+// NewStore creates a new store
+func NewStore(providers []Provider) *Store {
+	s := &Store{
+		providers: make(map[string]Provider),
+		cache:     make(map[string]map[string]string),
+	}
+	for _, p := range providers {
+		s.providers[p.Name()] = p
+	}
+
+	return s
+}
+
+// Resolve gets the secret a reference points to
+func (s *Store) Resolve(ctx context.Context, ref string) (string, error) {
+	name, path, key, err := parseReference(ref)
+	if err != nil {
+		return "", err
+	}
+
+	p, found := s.providers[name]
+	if !found {
+		return "", fmt.Errorf("secret provider %s is not configured", name)
+	}
+
+	// the secret is read without holding the lock, so a slow provider does not block the lookup of cached secrets
+	cacheKey := name + ":" + path
+	s.mu.Lock()
+	secret, found := s.cache[cacheKey]
+	s.mu.Unlock()
+	if !found {
+		secret, err = p.Read(ctx, path)
+		if err != nil {
+			return "", fmt.Errorf("could not read secret %s: %v", cacheKey, err)
+		}
+
+		s.mu.Lock()
+		s.cache[cacheKey] = secret
+		s.mu.Unlock()
+	}
+
+	v, found := secret[key]
+	if !found {
+		return "", fmt.Errorf("secret %s has no key %s", cacheKey, key)
+	}
+
+	return v, nil
+}
+
+func parseReference(ref string) (provider, path, key string, err error) {
+	i := strings.Index(ref, ":")
+	j := strings.LastIndex(ref, "#")
+	if i <= 0 || j < i+2 || j == len(ref)-1 {
+		return "", "", "", fmt.Errorf("invalid secret reference %q, expected <provider>:<path>#<key>", ref)
+	}
+
+	return ref[:i], ref[i+1 : j], ref[j+1:], nil
+}
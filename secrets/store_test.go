@@ -0,0 +1,63 @@
+package secrets
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// blockingProvider returns the secrets by path, reads of the path slow signal started and are blocked until release is closed
+type blockingProvider struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (p *blockingProvider) Name() string { return "fake" }
+
+func (p *blockingProvider) Read(ctx context.Context, path string) (map[string]string, error) {
+	if path == "slow" {
+		close(p.started)
+		select {
+		case <-p.release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	return map[string]string{"password": path}, nil
+}
+
+func TestResolveNotBlockedBySlowRead(t *testing.T) {
+	p := &blockingProvider{started: make(chan struct{}), release: make(chan struct{})}
+	s := NewStore([]Provider{p})
+
+	slow := make(chan error, 1)
+	go func() {
+		_, err := s.Resolve(context.Background(), "fake:slow#password")
+		slow <- err
+	}()
+	<-p.started
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := s.Resolve(context.Background(), "fake:fast#password")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("secret lookup blocked by slow read of another secret")
+	}
+
+	close(p.release)
+	if err := <-slow; err != nil {
+		t.Fatal(err)
+	}
+	v, err := s.Resolve(context.Background(), "fake:slow#password")
+	if err != nil || v != "slow" {
+		t.Fatalf("unexpected secret %q, %v", v, err)
+	}
+}
@@ -0,0 +1,148 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/lwlcom/cisco_exporter/config"
+)
+
+const vaultTimeout = 10 * time.Second
+
+// VaultProvider reads secrets from the KV secrets engine (version 1 or 2) of HashiCorp Vault
+type VaultProvider struct {
+	cfg    *config.VaultConfig
+	client *http.Client
+}
+
+// NewVaultProvider creates a new Vault provider. Address and token default to the environment variables
+// VAULT_ADDR and VAULT_TOKEN, the mount to secret and the KV version to 2
+func NewVaultProvider(cfg *config.VaultConfig) *VaultProvider {
+	return &VaultProvider{
+		cfg:    cfg,
+		client: &http.Client{Timeout: vaultTimeout},
+	}
+}
+
+// Name returns the name used to refer to the provider in secret references
+func (p *VaultProvider) Name() string {
+	return "vault"
+}
+
+// Read returns all key value pairs of the secret at path
+func (p *VaultProvider) Read(ctx context.Context, path string) (map[string]string, error) {
+	token, err := p.token()
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, p.url(path), nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("X-Vault-Token", token)
+	if len(p.cfg.Namespace) > 0 {
+		req.Header.Set("X-Vault-Namespace", p.cfg.Namespace)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Data json.RawMessage `json:"data"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&body)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode response: %v", err)
+	}
+
+	data := body.Data
+	if p.kvVersion() == 2 {
+		var v2 struct {
+			Data json.RawMessage `json:"data"`
+		}
+		err = json.Unmarshal(data, &v2)
+		if err != nil {
+			return nil, fmt.Errorf("could not decode response: %v", err)
+		}
+		data = v2.Data
+	}
+
+	values := make(map[string]interface{})
+	err = json.Unmarshal(data, &values)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode response: %v", err)
+	}
+
+	secret := make(map[string]string, len(values))
+	for k, v := range values {
+		if s, ok := v.(string); ok {
+			secret[k] = s
+		} else {
+			secret[k] = fmt.Sprint(v)
+		}
+	}
+
+	return secret, nil
+}
+
+func (p *VaultProvider) url(path string) string {
+	addr := p.cfg.Address
+	if len(addr) == 0 {
+		addr = os.Getenv("VAULT_ADDR")
+	}
+
+	mount := p.cfg.Mount
+	if len(mount) == 0 {
+		mount = "secret"
+	}
+
+	u := strings.TrimSuffix(addr, "/") + "/v1/" + strings.Trim(mount, "/") + "/"
+	if p.kvVersion() == 2 {
+		u += "data/"
+	}
+
+	return u + strings.TrimPrefix(path, "/")
+}
+
+func (p *VaultProvider) kvVersion() int {
+	if p.cfg.KVVersion == 1 {
+		return 1
+	}
+
+	return 2
+}
+
+func (p *VaultProvider) token() (string, error) {
+	if len(p.cfg.Token) > 0 {
+		return p.cfg.Token, nil
+	}
+
+	if len(p.cfg.TokenFile) > 0 {
+		b, err := ioutil.ReadFile(p.cfg.TokenFile)
+		if err != nil {
+			return "", fmt.Errorf("could not read token file: %v", err)
+		}
+		return strings.TrimSpace(string(b)), nil
+	}
+
+	if token := os.Getenv("VAULT_TOKEN"); len(token) > 0 {
+		return token, nil
+	}
+
+	return "", fmt.Errorf("no vault token configured")
+}
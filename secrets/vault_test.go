@@ -0,0 +1,139 @@
+package secrets
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/lwlcom/cisco_exporter/config"
+)
+
+const testToken = "s.test"
+
+type fakeVault struct {
+	mu      sync.Mutex
+	secrets map[string]string
+	reads   int
+}
+
+func (v *fakeVault) set(path, body string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.secrets[path] = body
+}
+
+func (v *fakeVault) readCount() int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.reads
+}
+
+func (v *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Vault-Token") != testToken {
+		http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+		return
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.reads++
+
+	body, found := v.secrets[r.URL.Path]
+	if !found {
+		http.Error(w, `{"errors":[]}`, http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(body))
+}
+
+func newFakeVault(t *testing.T) (*fakeVault, *httptest.Server) {
+	v := &fakeVault{secrets: make(map[string]string)}
+	srv := httptest.NewServer(v)
+	t.Cleanup(srv.Close)
+
+	return v, srv
+}
+
+func TestVaultKVv1(t *testing.T) {
+	v, srv := newFakeVault(t)
+	v.set("/v1/kv/network/core", `{"data":{"username":"exporter","password":"secret1"}}`)
+
+	s := NewStore([]Provider{NewVaultProvider(&config.VaultConfig{
+		Address:   srv.URL,
+		Token:     testToken,
+		Mount:     "kv",
+		KVVersion: 1,
+	})})
+
+	assertResolve(t, s, "vault:network/core#username", "exporter")
+	assertResolve(t, s, "vault:network/core#password", "secret1")
+}
+
+func TestVaultKVv2(t *testing.T) {
+	v, srv := newFakeVault(t)
+	v.set("/v1/secret/data/network/core", `{"data":{"data":{"password":"secret2","port":22},"metadata":{"version":3}}}`)
+
+	s := NewStore([]Provider{NewVaultProvider(&config.VaultConfig{
+		Address: srv.URL + "/",
+		Token:   testToken,
+	})})
+
+	assertResolve(t, s, "vault:/network/core#password", "secret2")
+	assertResolve(t, s, "vault:/network/core#port", "22")
+
+	if n := v.readCount(); n != 1 {
+		t.Fatalf("expected secret to be read once, got %d reads", n)
+	}
+}
+
+func TestVaultMissingKey(t *testing.T) {
+	v, srv := newFakeVault(t)
+	v.set("/v1/secret/data/network/core", `{"data":{"data":{"password":"secret2"}}}`)
+
+	s := NewStore([]Provider{NewVaultProvider(&config.VaultConfig{
+		Address: srv.URL,
+		Token:   testToken,
+	})})
+
+	_, err := s.Resolve(context.Background(), "vault:network/core#enable")
+	if err == nil || !strings.Contains(err.Error(), "has no key enable") {
+		t.Fatalf("expected missing key error, got %v", err)
+	}
+
+	_, err = s.Resolve(context.Background(), "vault:network/edge#password")
+	if err == nil || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("expected missing secret error, got %v", err)
+	}
+}
+
+func TestVaultReload(t *testing.T) {
+	v, srv := newFakeVault(t)
+	v.set("/v1/secret/data/network/core", `{"data":{"data":{"password":"old"}}}`)
+
+	cfg := &config.Config{Vault: &config.VaultConfig{Address: srv.URL, Token: testToken}}
+	s := NewStore(ProvidersForConfig(cfg))
+	assertResolve(t, s, "vault:network/core#password", "old")
+
+	v.set("/v1/secret/data/network/core", `{"data":{"data":{"password":"new"}}}`)
+	assertResolve(t, s, "vault:network/core#password", "old")
+
+	// a reload creates a new store which has to read the secret again
+	s = NewStore(ProvidersForConfig(cfg))
+	assertResolve(t, s, "vault:network/core#password", "new")
+}
+
+func assertResolve(t *testing.T, s *Store, ref, expected string) {
+	t.Helper()
+
+	v, err := s.Resolve(context.Background(), ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != expected {
+		t.Fatalf("%s: expected %q, got %q", ref, expected, v)
+	}
+}